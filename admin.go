@@ -0,0 +1,191 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"runtime/pprof"
+	"runtime/trace"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WithAdminServer makes the daemon serve an internal http server on addr (e.g. "127.0.0.1:9090"), from `Start` until the graceful shutdown is done:
+//
+//	/healthz:      200 until the graceful shutdown is done (liveness).
+//	/readyz:       200 while running, 503 once the shutdown has started (see `ReadyzHandler`).
+//	/health:       the JSON `HealthReport` of the registered health checks (see `HealthHandler`).
+//	/status:       the JSON `StatusReport` of the daemon (see `StatusHandler`).
+//	/debug/pprof/: the runtime profiles, like `net/http/pprof`.
+//	/shutdown:     initiates the graceful shutdown (POST only), if enabled using `WithAdminShutdown`.
+//
+// The server should not be exposed publicly.
+func WithAdminServer(addr string) DaemonConfigOption {
+	return func(oc *config) {
+		oc.adminAddr = addr
+	}
+}
+
+// WithAdminShutdown enables the `/shutdown` endpoint of the admin server (see `WithAdminServer`). It is disabled by default,
+// since anyone that can reach the admin server could stop the daemon.
+func WithAdminShutdown() DaemonConfigOption {
+	return func(oc *config) {
+		oc.adminShutdown = true
+	}
+}
+
+type adminServer struct {
+	ln  net.Listener
+	srv *http.Server
+	wg  sync.WaitGroup
+}
+
+// startAdminServer starts the admin server, if configured.
+func (o *Daemon) startAdminServer() {
+	if o.config.adminAddr == "" {
+		return
+	}
+
+	ln, err := net.Listen("tcp", o.config.adminAddr)
+	if err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to listen for the admin server", slog.String("addr", o.config.adminAddr), slog.String("error", err.Error()))
+		return
+	}
+
+	o.admin = &adminServer{
+		ln: ln,
+		srv: &http.Server{
+			Handler:           o.adminMux(),
+			ReadHeaderTimeout: 10 * time.Second,
+		},
+	}
+
+	o.admin.wg.Go(func() {
+		if err := o.admin.srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+			o.config.logger.ErrorContext(o.ctx, "admin server failed", slog.String("error", err.Error()))
+		}
+	})
+
+	o.config.logger.InfoContext(o.ctx, "admin server started", slog.String("addr", ln.Addr().String()))
+}
+
+// stopAdminServer closes the admin server and waits for it to finish.
+func (o *Daemon) stopAdminServer() {
+	if o.admin == nil {
+		return
+	}
+
+	_ = o.admin.srv.Close()
+	o.admin.wg.Wait()
+}
+
+func (o *Daemon) adminMux() *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.Handle("/readyz", o.ReadyzHandler())
+	mux.Handle("/health", o.HealthHandler())
+	mux.Handle("/status", o.StatusHandler())
+	if o.config.adminShutdown {
+		mux.HandleFunc("POST /shutdown", func(w http.ResponseWriter, _ *http.Request) {
+			o.config.logger.InfoContext(o.ctx, "shutdown requested through the admin server")
+			o.ShutDown()
+			w.WriteHeader(http.StatusAccepted)
+		})
+	}
+
+	mux.HandleFunc("/debug/pprof/", pprofIndex)
+	mux.HandleFunc("/debug/pprof/profile", pprofCPU)
+	mux.HandleFunc("/debug/pprof/trace", pprofTrace)
+
+	return mux
+}
+
+// pprofIndex serves the named runtime profile (e.g. /debug/pprof/heap?debug=1), or the list of the profiles.
+func pprofIndex(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/debug/pprof/")
+	if name == "" {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		profiles := pprof.Profiles()
+		slices.SortFunc(profiles, func(a, b *pprof.Profile) int { return strings.Compare(a.Name(), b.Name()) })
+		for _, p := range profiles {
+			_, _ = fmt.Fprintf(w, "%d\t%s\n", p.Count(), p.Name())
+		}
+		_, _ = fmt.Fprintln(w, "-\tprofile")
+		_, _ = fmt.Fprintln(w, "-\ttrace")
+
+		return
+	}
+
+	p := pprof.Lookup(name)
+	if p == nil {
+		http.Error(w, "unknown profile", http.StatusNotFound)
+		return
+	}
+
+	debug, _ := strconv.Atoi(r.FormValue("debug"))
+	if debug == 0 {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	} else {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	_ = p.WriteTo(w, debug)
+}
+
+// pprofCPU serves the CPU profile for the duration given by the seconds parameter (30 by default).
+func pprofCPU(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="profile"`)
+	if err := pprof.StartCPUProfile(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer pprof.StopCPUProfile()
+
+	sleepRequest(r, profileDuration(r))
+}
+
+// pprofTrace serves the execution trace for the duration given by the seconds parameter (1 by default).
+func pprofTrace(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	w.Header().Set("Content-Disposition", `attachment; filename="trace"`)
+	if err := trace.Start(w); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer trace.Stop()
+
+	d := time.Second
+	if r.FormValue("seconds") != "" {
+		d = profileDuration(r)
+	}
+	sleepRequest(r, d)
+}
+
+func profileDuration(r *http.Request) time.Duration {
+	sec, err := strconv.ParseFloat(r.FormValue("seconds"), 64)
+	if err != nil || sec <= 0 {
+		sec = 30
+	}
+
+	return time.Duration(sec * float64(time.Second))
+}
+
+// sleepRequest waits for d, or until the request is done.
+func sleepRequest(r *http.Request, d time.Duration) {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-r.Context().Done():
+	}
+}
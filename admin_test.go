@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminServer(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithAdminServer("127.0.0.1:0"), WithAdminShutdown(), WithLogger(logger(t)), withSTDAPI(s))
+	require.NotNil(t, d.admin)
+	base := "http://" + d.admin.ln.Addr().String()
+
+	get := func(path string) (int, string) {
+		resp, err := http.Get(base + path) //nolint:noctx // test.
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+
+		return resp.StatusCode, string(b)
+	}
+
+	tests := map[string]struct {
+		path         string
+		expectedCode int
+		contains     string
+	}{
+		"healthz":       {path: "/healthz", expectedCode: http.StatusOK, contains: "ok"},
+		"readyz":        {path: "/readyz", expectedCode: http.StatusOK, contains: "ok"},
+		"status":        {path: "/status", expectedCode: http.StatusOK, contains: `"status":"running"`},
+		"pprof index":   {path: "/debug/pprof/", expectedCode: http.StatusOK, contains: "goroutine"},
+		"pprof profile": {path: "/debug/pprof/goroutine?debug=1", expectedCode: http.StatusOK, contains: "goroutine profile"},
+		"pprof unknown": {path: "/debug/pprof/unknown", expectedCode: http.StatusNotFound},
+		"shutdown get":  {path: "/shutdown", expectedCode: http.StatusMethodNotAllowed},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			code, body := get(tc.path)
+			assert.Equal(t, tc.expectedCode, code)
+			assert.Contains(t, body, tc.contains)
+		})
+	}
+
+	readyzCode := 0
+	d.Defer(func(context.Context) {
+		readyzCode, _ = get("/readyz")
+	})
+
+	resp, err := http.Post(base+"/shutdown", "", nil) //nolint:noctx // test.
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+
+	d.Wait()
+	assert.Equal(t, ReasonManual, d.ShutdownReason())
+	assert.Equal(t, http.StatusServiceUnavailable, readyzCode)
+
+	// the server is closed once the shutdown is done.
+	_, err = http.Get(base + "/healthz") //nolint:noctx // test.
+	assert.Error(t, err)
+}
+
+func TestAdminServerShutdownDisabled(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithAdminServer("127.0.0.1:0"), WithLogger(logger(t)), withSTDAPI(s))
+	require.NotNil(t, d.admin)
+
+	resp, err := http.Post("http://"+d.admin.ln.Addr().String()+"/shutdown", "", nil) //nolint:noctx // test.
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, StatusRunning, d.State().Status)
+
+	d.ShutDown()
+	d.Wait()
+}
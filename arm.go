@@ -0,0 +1,48 @@
+package daemon
+
+import (
+	"log/slog"
+	"runtime/trace"
+)
+
+// WithDisarmedStart makes the daemon start disarmed: the signal handlers are installed at `Start`, but the stop conditions are not acted upon until `Arm` is called.
+// The first stop condition met while disarmed is queued and honored as soon as the daemon is armed, so a long bootstrap is not interrupted by a shutdown
+// that would run the shutdown callbacks against half initialized modules. The immediate termination (see `WithMaxSignalCount`) is not affected.
+func WithDisarmedStart() DaemonConfigOption {
+	return func(oc *config) {
+		oc.disarmedStart = true
+	}
+}
+
+// Arm arms a daemon started using `WithDisarmedStart`, and initiates the shutdown if a stop condition was met meanwhile. It is a no-op if the daemon is already armed.
+func (o *Daemon) Arm() {
+	defer trace.StartRegion(o.ctx, "arm").End()
+
+	o.mu.Lock()
+	pending := o.pending
+	o.disarmed = false
+	o.pending = nil
+	o.mu.Unlock()
+
+	if pending != nil {
+		o.config.logger.InfoContext(o.ctx, "daemon armed, honoring the queued stop condition", slog.String("reason", string(pending.Reason)))
+		o.initiateShutdown(*pending)
+	}
+}
+
+// queueTrigger queues the first trigger while the daemon is disarmed. It returns false if the daemon is armed.
+func (o *Daemon) queueTrigger(info ShutdownInfo) bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if !o.disarmed {
+		return false
+	}
+
+	if o.pending == nil {
+		o.config.logger.InfoContext(o.ctx, "daemon is not armed, queueing the stop condition", slog.String("reason", string(info.Reason)))
+		o.pending = &info
+	}
+
+	return true
+}
@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDisarmedStart(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithDisarmedStart(), WithLogger(logger(t)), withSTDAPI(s))
+
+	d.signalCh <- os.Interrupt
+	d.FatalErrorsChannel() <- errors.New("error")
+
+	assert.ErrorIs(t, d.WaitTimeout(50*time.Millisecond), context.DeadlineExceeded)
+	assert.Equal(t, StatusRunning, d.State().Status)
+
+	d.Arm()
+
+	d.Wait()
+	// only the first stop condition is queued.
+	assert.Equal(t, ReasonSignal, d.State().Reason)
+}
+
+func TestArmWithoutQueued(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithDisarmedStart(), WithLogger(logger(t)), withSTDAPI(s))
+	d.Arm()
+
+	assert.ErrorIs(t, d.WaitTimeout(20*time.Millisecond), context.DeadlineExceeded)
+
+	d.ShutDown()
+	d.Wait()
+	assert.Equal(t, ReasonManual, d.State().Reason)
+}
@@ -0,0 +1,363 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"reflect"
+	"runtime"
+	"runtime/trace"
+	"strings"
+	"sync"
+	"time"
+)
+
+// CallbackInfo describes the shutdown callback that is running.
+type CallbackInfo struct {
+	// Name of the callback. Callbacks are named after their function (e.g. `main.(*httpModule).ShutDown`).
+	Name string
+	// Position of the callback in the execution order, starting from zero.
+	Position int
+	// Total is the number of the shutdown callbacks.
+	Total int
+	// Deadline of the callback. It is zero if the grace period is infinite.
+	Deadline time.Time
+	// Reason is the stop condition that initiated the shutdown.
+	Reason Reason
+	// Phase the callback runs in: one of the drain stages for the hooks registered using `OnStageWithInfo`, or `PhaseCallbacks`.
+	// For the drain stage hooks, the position and the total are within the stage.
+	Phase Phase
+}
+
+type callback struct {
+	name   string
+	fn     func(context.Context, CallbackInfo)
+	weight int
+}
+
+// WithGraceBudgeting divides the shutdown grace period among the shutdown callbacks, according to their weights (see `DeferWeighted`, the default weight is 1),
+// instead of all of them sharing a single deadline that the first slow callback can exhaust.
+// Each callback receives a context with its own deadline, and the budget a callback does not use rolls over to the next ones.
+// It has no effect if the grace period is infinite.
+func WithGraceBudgeting() DaemonConfigOption {
+	return func(oc *config) {
+		oc.graceBudgeting = true
+	}
+}
+
+// DeferWeighted is like `Defer`, but sets the weight of the given functions in the grace period division (see `WithGraceBudgeting`).
+func (o *Daemon) DeferWeighted(weight int, f ...func(context.Context)) {
+	cbs := callbacks(f)
+	for i := range cbs {
+		cbs[i].weight = weight
+	}
+
+	o.onShutDownMutex.Lock()
+	defer o.onShutDownMutex.Unlock()
+	o.onShutDown = pushFront(o.onShutDown, cbs...)
+}
+
+// DeferNamed pushes the function to be called on shutdown with the given name, like `Defer` (last in first out).
+// The name is used in the logs and the progress reports, instead of the function's name (e.g. for closures, which are named `main.main.func1`).
+func (o *Daemon) DeferNamed(name string, f func(context.Context)) {
+	o.deferNamed(name, f)
+}
+
+// OnShutDownNamed appends the function to be called on shutdown with the given name, like `OnShutDown` (first in first out).
+// The name is used like the one given to `DeferNamed`.
+func (o *Daemon) OnShutDownNamed(name string, f func(context.Context)) {
+	if f == nil {
+		return
+	}
+
+	o.onShutDownMutex.Lock()
+	defer o.onShutDownMutex.Unlock()
+	o.onShutDown = append(o.onShutDown, callback{name: name, fn: func(ctx context.Context, _ CallbackInfo) { f(ctx) }})
+}
+
+// OnShutDownE appends functions that return an error to be called on shutdown, like `OnShutDown` (first in first out).
+// The errors are collected, wrapped with the name of the callback, and returned by `Err`. They are also logged once the shutdown is completed.
+func (o *Daemon) OnShutDownE(f ...func(context.Context) error) {
+	cbs := make([]callback, 0, len(f))
+	for _, fn := range f {
+		cbs = append(cbs, o.errCallback(funcName(fn), fn))
+	}
+
+	o.onShutDownMutex.Lock()
+	defer o.onShutDownMutex.Unlock()
+	o.onShutDown = append(o.onShutDown, cbs...)
+}
+
+// DeferE pushes cleanup functions that return an error and do not take a context, like `Defer` (last in first out).
+// Their errors are collected like the ones of `OnShutDownE`.
+func (o *Daemon) DeferE(f ...func() error) {
+	cbs := make([]callback, 0, len(f))
+	for _, fn := range f {
+		cbs = append(cbs, o.errCallback(funcName(fn), func(context.Context) error { return fn() }))
+	}
+
+	o.onShutDownMutex.Lock()
+	defer o.onShutDownMutex.Unlock()
+	o.onShutDown = pushFront(o.onShutDown, cbs...)
+}
+
+// DeferClose pushes the closers (e.g. `*sql.DB`, `*os.File`) to be closed on shutdown, like `Defer` (last in first out).
+// The callbacks are named after the closers' types, and their errors are collected like the ones of `OnShutDownE`.
+func (o *Daemon) DeferClose(closers ...io.Closer) {
+	cbs := make([]callback, 0, len(closers))
+	for _, c := range closers {
+		cbs = append(cbs, o.errCallback(fmt.Sprintf("%T.Close", c), func(context.Context) error { return c.Close() }))
+	}
+
+	o.onShutDownMutex.Lock()
+	defer o.onShutDownMutex.Unlock()
+	o.onShutDown = pushFront(o.onShutDown, cbs...)
+}
+
+// errCallback returns a callback that collects the error of fn, wrapped with the name of the callback (see `Err`).
+func (o *Daemon) errCallback(name string, fn func(context.Context) error) callback {
+	return callback{
+		name: name,
+		fn: func(ctx context.Context, _ CallbackInfo) {
+			if err := fn(ctx); err != nil {
+				o.addCallbackError(fmt.Errorf("%s: %w", name, err))
+			}
+		},
+	}
+}
+
+// Err returns the errors of the shutdown callbacks registered using `OnShutDownE`, `DeferE` or `DeferClose` and the panics of the shutdown callbacks (see `WithPanicHandler`), joined,
+// or nil if none failed. It is complete once `Wait` returns.
+func (o *Daemon) Err() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return errors.Join(o.callbackErrs...)
+}
+
+func (o *Daemon) addCallbackError(err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.callbackErrs = append(o.callbackErrs, err)
+}
+
+// WithSlowCallbackThreshold sets how long a shutdown callback can run before a warning is logged, while it is still running. The default is 5 seconds, zero disables the warning.
+func WithSlowCallbackThreshold(d time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.slowCallbackThreshold = d
+	}
+}
+
+// callbackLogObserver logs every shutdown callback when it starts and finishes, along with its duration,
+// and warns about the callbacks that run longer than the slowness threshold (see `WithSlowCallbackThreshold`).
+func (o *Daemon) callbackLogObserver() observer {
+	mu := sync.Mutex{}
+	timers := map[int]*time.Timer{}
+	slow := o.config.slowCallbackThreshold
+
+	return observer{
+		callbackStarted: func(ctx context.Context, info CallbackInfo) {
+			o.config.logger.InfoContext(ctx, "running shutdown callback", slog.String("callback", info.Name), slog.Int("position", info.Position))
+			if slow <= 0 {
+				return
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			timers[info.Position] = time.AfterFunc(slow, func() {
+				o.config.logger.WarnContext(ctx, "shutdown callback is slow", slog.String("callback", info.Name), slog.Duration("threshold", slow))
+			})
+		},
+		callbackFinished: func(ctx context.Context, info CallbackInfo, elapsed time.Duration) {
+			mu.Lock()
+			if t, found := timers[info.Position]; found {
+				t.Stop()
+				delete(timers, info.Position)
+			}
+			mu.Unlock()
+
+			o.config.logger.InfoContext(ctx, "shutdown callback done",
+				slog.String("callback", info.Name),
+				slog.Duration("duration", elapsed),
+				slog.Bool("slow", slow > 0 && elapsed > slow),
+			)
+		},
+	}
+}
+
+// budgetWeight returns the weight of the callback in the grace period division.
+func (c callback) budgetWeight() int {
+	return max(c.weight, 1)
+}
+
+// DeferWithInfo is like `Defer`, but the given functions receive also a `CallbackInfo` that describes the running callback.
+func (o *Daemon) DeferWithInfo(f ...func(context.Context, CallbackInfo)) {
+	cbs := make([]callback, 0, len(f))
+	for _, fn := range f {
+		cbs = append(cbs, callback{name: funcName(fn), fn: fn})
+	}
+
+	o.onShutDownMutex.Lock()
+	defer o.onShutDownMutex.Unlock()
+	o.onShutDown = pushFront(o.onShutDown, cbs...)
+}
+
+// callbackNames returns the names of the shutdown callbacks, in execution order.
+func (o *Daemon) callbackNames() []string {
+	o.onShutDownMutex.Lock()
+	defer o.onShutDownMutex.Unlock()
+
+	names := make([]string, 0, len(o.onShutDown))
+	for _, cb := range o.onShutDown {
+		names = append(names, cb.name)
+	}
+
+	return names
+}
+
+// callbacks converts plain shutdown functions to callbacks named after each function.
+func callbacks(f []func(context.Context)) []callback {
+	cbs := make([]callback, 0, len(f))
+	for _, fn := range f {
+		cbs = append(cbs, callback{
+			name: funcName(fn),
+			fn:   func(ctx context.Context, _ CallbackInfo) { fn(ctx) },
+		})
+	}
+
+	return cbs
+}
+
+// WithShutdownConcurrency runs up to n shutdown callbacks concurrently, in a worker pool, instead of strictly sequentially (the default),
+// shortening the shutdown of services with many independent resources. The callbacks still start in their execution order,
+// so it should only be used when the callbacks do not depend on each other (e.g. `CancelCTX` can not be used to order them).
+// `WithGraceBudgeting` has no effect on concurrent callbacks, they all share the grace period. Values below 2 mean sequential.
+func WithShutdownConcurrency(n int) DaemonConfigOption {
+	return func(oc *config) {
+		oc.shutdownConcurrency = n
+	}
+}
+
+// runCallbacks runs every shutdown callback sequentially (or concurrently, see `WithShutdownConcurrency`), until the ctx is done.
+func (o *Daemon) runCallbacks(ctx context.Context, reason Reason) {
+	o.onShutDownMutex.Lock()
+	defer o.onShutDownMutex.Unlock()
+
+	if o.config.shutdownConcurrency > 1 {
+		o.runCallbacksConcurrently(ctx, reason, o.config.shutdownConcurrency)
+		return
+	}
+
+	deadline, hasDeadline := ctx.Deadline()
+	budgeting := o.config.graceBudgeting && hasDeadline
+
+	remainingWeight := 0
+	for _, cb := range o.onShutDown {
+		remainingWeight += cb.budgetWeight()
+	}
+
+	for i, cb := range o.onShutDown {
+		if ctx.Err() != nil || o.skipCallbacks.Load() {
+			return
+		}
+
+		cbCTX, cancel := ctx, context.CancelFunc(func() {})
+		if budgeting {
+			// the remaining time, including the budget unused by the previous callbacks, is divided among the remaining callbacks.
+			cbCTX, cancel = context.WithTimeout(ctx, time.Until(deadline)*time.Duration(cb.budgetWeight())/time.Duration(remainingWeight))
+			remainingWeight -= cb.budgetWeight()
+		}
+
+		o.runCallback(cbCTX, cb, i, reason)
+		cancel()
+	}
+}
+
+// runCallbacksConcurrently runs the shutdown callbacks in a pool of n workers, starting them in execution order until the ctx is done.
+// It should be called while holding `onShutDownMutex`.
+func (o *Daemon) runCallbacksConcurrently(ctx context.Context, reason Reason, n int) {
+	sem := make(chan struct{}, n)
+	wg := sync.WaitGroup{}
+	defer wg.Wait()
+
+	for i, cb := range o.onShutDown {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+
+		if ctx.Err() != nil || o.skipCallbacks.Load() {
+			return
+		}
+
+		wg.Go(func() {
+			defer func() { <-sem }()
+			o.runCallback(ctx, cb, i, reason)
+		})
+	}
+}
+
+// runCallback runs the shutdown callback at position i, reporting it to the observers. The progress reports consider it the running callback.
+func (o *Daemon) runCallback(ctx context.Context, cb callback, i int, reason Reason) {
+	deadline, _ := ctx.Deadline()
+
+	info := CallbackInfo{
+		Name:     cb.name,
+		Position: i,
+		Total:    len(o.onShutDown),
+		Deadline: deadline,
+		Reason:   reason,
+		Phase:    PhaseCallbacks,
+	}
+
+	o.callbackPosition.Store(int64(i))
+	o.notifyCallbackStarted(ctx, info)
+	start := time.Now()
+	trace.WithRegion(ctx, cb.name, func() {
+		defer o.recoverCallback(cb.name)
+		cb.fn(ctx, info)
+	})
+	o.notifyCallbackFinished(ctx, info, time.Since(start))
+}
+
+// funcName returns the name of the given function, without its package path.
+func funcName(f any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return ""
+	}
+
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	// method values are suffixed with -fm.
+	return strings.TrimSuffix(name, "-fm")
+}
+
+// WaitUntil is meant to be used inside shutdown callbacks: it polls cond every interval (checking it immediately first)
+// until it returns true, or until the ctx is done (e.g. the grace period, or the callback's budget when using `WithGraceBudgeting`, is exhausted).
+// It returns nil if cond was satisfied, or the ctx's error otherwise.
+func WaitUntil(ctx context.Context, interval time.Duration, cond func() bool) error {
+	if cond() {
+		return nil
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if cond() {
+				return nil
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,286 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDeferWithInfo(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(
+		context.Background(),
+		WithShutdownGraceDuration(time.Minute),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	infos := []CallbackInfo{}
+	record := func(_ context.Context, info CallbackInfo) { infos = append(infos, info) }
+
+	d.DeferWithInfo(record)
+	d.Defer(func(context.Context) {})
+	d.DeferWithInfo(record)
+
+	stageInfos := []CallbackInfo{}
+	d.OnStageWithInfo(PhaseWorkers, func(_ context.Context, info CallbackInfo) error {
+		stageInfos = append(stageInfos, info)
+		return nil
+	})
+
+	d.ShutDown()
+	d.Wait()
+
+	if assert.Len(t, stageInfos, 1) {
+		assert.Equal(t, "daemon.TestDeferWithInfo.func3", stageInfos[0].Name)
+		assert.Equal(t, PhaseWorkers, stageInfos[0].Phase)
+		assert.Equal(t, 0, stageInfos[0].Position)
+		assert.Equal(t, 1, stageInfos[0].Total)
+		assert.Equal(t, ReasonManual, stageInfos[0].Reason)
+	}
+
+	if assert.Len(t, infos, 2) {
+		assert.Equal(t, 0, infos[0].Position)
+		assert.Equal(t, 2, infos[1].Position)
+		for _, info := range infos {
+			assert.Equal(t, "daemon.TestDeferWithInfo.func1", info.Name)
+			assert.Equal(t, 3, info.Total)
+			assert.Equal(t, ReasonManual, info.Reason)
+			assert.Equal(t, PhaseCallbacks, info.Phase)
+			assert.WithinDuration(t, time.Now().Add(time.Minute), info.Deadline, 5*time.Second)
+		}
+	}
+}
+
+type namedModule struct{}
+
+func (namedModule) Stop(context.Context) {}
+
+func TestFuncName(t *testing.T) {
+	m := namedModule{}
+	assert.Equal(t, "daemon.namedModule.Stop", funcName(m.Stop))
+	assert.Equal(t, "daemon.TestFuncName", funcName(TestFuncName))
+	assert.Equal(t, "daemon.TestFuncName.func1", funcName(func() {}))
+}
+
+func TestGraceBudgeting(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithShutdownGraceDuration(300*time.Millisecond),
+		WithGraceBudgeting(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	var lastCTXErr error
+	budgets := []time.Duration{}
+	record := func(ctx context.Context, info CallbackInfo) {
+		budgets = append(budgets, time.Until(info.Deadline))
+		lastCTXErr = ctx.Err()
+	}
+
+	// registered in reverse order: fast, slow (weight 2), last.
+	d.DeferWithInfo(record)
+	d.DeferWeighted(2, func(ctx context.Context) { <-ctx.Done() })
+	d.DeferWithInfo(record)
+
+	d.ShutDown()
+	d.Wait()
+
+	if assert.Len(t, budgets, 2) {
+		// the fast callback gets a quarter of the grace period.
+		assert.InDelta(t, 75*time.Millisecond, budgets[0], float64(20*time.Millisecond))
+		// the slow one exhausts only its own share (two thirds of the rest), so the last one still has a budget.
+		assert.InDelta(t, 100*time.Millisecond, budgets[1], float64(20*time.Millisecond))
+	}
+	assert.NoError(t, lastCTXErr)
+}
+
+func TestWaitUntil(t *testing.T) {
+	assert.NoError(t, WaitUntil(t.Context(), time.Hour, func() bool { return true }))
+
+	calls := 0
+	assert.NoError(t, WaitUntil(t.Context(), time.Millisecond, func() bool {
+		calls++
+		return calls == 3
+	}))
+	assert.Equal(t, 3, calls)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, WaitUntil(ctx, time.Millisecond, func() bool { return false }), context.DeadlineExceeded)
+}
+
+func TestShutdownConcurrency(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), WithShutdownConcurrency(2))
+
+	running := atomic.Int64{}
+	maxRunning := atomic.Int64{}
+	done := atomic.Int64{}
+	cb := func(context.Context) {
+		n := running.Add(1)
+		for {
+			m := maxRunning.Load()
+			if n <= m || maxRunning.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		running.Add(-1)
+		done.Add(1)
+	}
+	d.Defer(cb, cb, cb, cb)
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.Equal(t, int64(4), done.Load())
+	assert.Equal(t, int64(2), maxRunning.Load())
+}
+
+// lockedBuffer is a bytes.Buffer safe for concurrent use.
+type lockedBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestCallbackLogging(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	out := &lockedBuffer{}
+	d := Start(
+		context.Background(),
+		WithLogger(slog.New(slog.NewTextHandler(out, nil))),
+		withSTDAPI(s),
+		WithSlowCallbackThreshold(10*time.Millisecond),
+	)
+
+	d.DeferNamed("kafka-consumer", func(context.Context) { time.Sleep(50 * time.Millisecond) })
+	d.Defer(namedModule{}.Stop)
+
+	d.ShutDown()
+	d.Wait()
+
+	logs := out.String()
+	assert.Contains(t, logs, `msg="running shutdown callback" instance_id=`+d.instanceID+` callback=daemon.namedModule.Stop position=0`)
+	assert.Contains(t, logs, `msg="running shutdown callback" instance_id=`+d.instanceID+` callback=kafka-consumer position=1`)
+	assert.Contains(t, logs, `msg="shutdown callback is slow" instance_id=`+d.instanceID+` callback=kafka-consumer threshold=10ms`)
+	assert.Regexp(t, `msg="shutdown callback done" instance_id=\S+ callback=kafka-consumer duration=\S+ slow=true`, logs)
+	assert.Regexp(t, `msg="shutdown callback done" instance_id=\S+ callback=daemon.namedModule.Stop duration=\S+ slow=false`, logs)
+}
+
+func TestOnShutDownE(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	errDB := errors.New("db close failed")
+	errCache := errors.New("cache close failed")
+	d.OnShutDownE(
+		func(context.Context) error { return errDB },
+		func(context.Context) error { return nil },
+		func(context.Context) error { return errCache },
+	)
+
+	d.ShutDown()
+	d.Wait()
+
+	err := d.Err()
+	assert.ErrorIs(t, err, errDB)
+	assert.ErrorIs(t, err, errCache)
+	// first in first out.
+	assert.Regexp(t, `^daemon\.TestOnShutDownE\.func\d+: db close failed\ndaemon\.TestOnShutDownE\.func\d+: cache close failed$`, err.Error())
+}
+
+type fakeCloser struct {
+	err    error
+	closed bool
+}
+
+func (c *fakeCloser) Close() error {
+	c.closed = true
+	return c.err
+}
+
+func TestDeferCloseAndDeferE(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	errClose := errors.New("close failed")
+	errFlush := errors.New("flush failed")
+	closer := &fakeCloser{err: errClose}
+	okCloser := &fakeCloser{}
+	d.DeferClose(closer, okCloser)
+	d.DeferE(func() error { return errFlush })
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.True(t, closer.closed)
+	assert.True(t, okCloser.closed)
+
+	err := d.Err()
+	assert.ErrorIs(t, err, errClose)
+	assert.ErrorIs(t, err, errFlush)
+	// last in first out.
+	assert.Regexp(t, `^daemon\.TestDeferCloseAndDeferE\.func\d+: flush failed\n\*daemon\.fakeCloser\.Close: close failed$`, err.Error())
+}
+
+func TestNamedCallbacksOrder(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	noop := func(context.Context) {}
+	d.OnShutDownNamed("first", noop)
+	d.OnShutDownNamed("second", noop)
+	d.DeferNamed("third", noop)
+	d.DeferNamed("fourth", noop)
+
+	// OnShutDownNamed appends (first in first out), DeferNamed pushes to the front (last in first out).
+	assert.Equal(t, []string{"fourth", "third", "first", "second"}, d.callbackNames())
+
+	d.ShutDown()
+	d.Wait()
+}
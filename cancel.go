@@ -0,0 +1,29 @@
+package daemon
+
+// ContextCancelPolicy describes when the daemon cancels its context (`CTX()`) during the shutdown.
+type ContextCancelPolicy int
+
+const (
+	// CancelAfterCallbacks cancels the context after every shutdown callback is done. This is the default policy.
+	CancelAfterCallbacks ContextCancelPolicy = iota
+	// CancelBeforeCallbacks cancels the context before the drain stages and the shutdown callbacks, right after the leadership resignation,
+	// the drain delay (see `WithDrainDelay`) and the closing of the listeners (see `Listen`), so the daemon keeps serving during the drain delay.
+	CancelBeforeCallbacks
+	// CancelManual never cancels the context, unless `CancelCTX` is registered as a shutdown callback (or the parent context is done).
+	CancelManual
+)
+
+// WithContextCancelPolicy sets when the daemon's context gets cancelled during the shutdown.
+// `CancelCTX` can still be registered as a shutdown callback for orderings that are not covered by the policies.
+func WithContextCancelPolicy(p ContextCancelPolicy) DaemonConfigOption {
+	return func(oc *config) {
+		oc.contextCancelPolicy = p
+	}
+}
+
+// WithNoAutoCancel makes the daemon never cancel its context itself, unless `CancelCTX` is registered as a shutdown callback.
+// This is useful when tasks that run after `Wait()` (e.g. a final metrics push) still need a live context.
+// It is a shorthand for `WithContextCancelPolicy(CancelManual)`.
+func WithNoAutoCancel() DaemonConfigOption {
+	return WithContextCancelPolicy(CancelManual)
+}
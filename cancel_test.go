@@ -0,0 +1,72 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestContextCancelPolicy(t *testing.T) {
+	tests := map[string]struct {
+		policy            ContextCancelPolicy
+		canceledInCB      bool
+		canceledAfterWait bool
+	}{
+		"after callbacks": {
+			policy:            CancelAfterCallbacks,
+			canceledInCB:      false,
+			canceledAfterWait: true,
+		},
+		"before callbacks": {
+			policy:            CancelBeforeCallbacks,
+			canceledInCB:      true,
+			canceledAfterWait: true,
+		},
+		"manual": {
+			policy:            CancelManual,
+			canceledInCB:      false,
+			canceledAfterWait: false,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			// we specifically want a context that will not get cancelled at the end of the test
+			d := Start(
+				context.Background(),
+				WithContextCancelPolicy(tc.policy),
+				WithLogger(logger(t)),
+				withSTDAPI(s),
+			)
+
+			var canceledInCB bool
+			d.Defer(func(context.Context) { canceledInCB = d.CTX().Err() != nil })
+
+			d.ShutDown()
+			d.Wait()
+
+			assert.Equal(t, tc.canceledInCB, canceledInCB)
+			assert.Equal(t, tc.canceledAfterWait, d.CTX().Err() != nil)
+		})
+	}
+}
+
+func TestNoAutoCancel(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithNoAutoCancel(), WithLogger(logger(t)), withSTDAPI(s))
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.NoError(t, d.CTX().Err())
+}
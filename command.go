@@ -0,0 +1,174 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// ErrCommandExited is pushed (wrapped) to the fatal errors channel when a command managed using `Command` exits while the daemon is running.
+var ErrCommandExited = errors.New("command exited unexpectedly")
+
+// CommandOption configures a command managed using `Command`.
+type CommandOption func(*commandConfig)
+
+type commandConfig struct {
+	processGroup   bool
+	forward        bool
+	forwardSignals []os.Signal
+}
+
+// WithProcessGroup starts the command in its own process group and delivers the termination and kill signals to the whole group,
+// so descendants of the command (e.g. processes spawned by shell wrappers) do not outlive the shutdown.
+// On windows, the command is placed in a job object that kills every process of the job once the command exits or the daemon's process exits, even forcibly.
+func WithProcessGroup() CommandOption {
+	return func(cc *commandConfig) {
+		cc.processGroup = true
+	}
+}
+
+// WithSignalForwarding forwards the given signals, when received by the daemon, to the command (or its whole process group, see `WithProcessGroup`),
+// e.g. for wrappers around a sidecar process. Without any signals, every signal the daemon receives is forwarded.
+// A forwarded stop signal still initiates the daemon's shutdown, which then terminates the command as usual.
+func WithSignalForwarding(signals ...os.Signal) CommandOption {
+	return func(cc *commandConfig) {
+		cc.forward = true
+		cc.forwardSignals = signals
+	}
+}
+
+// Command starts the given command and manages it for the lifetime of the daemon.
+// If the command exits while the daemon is running, an `ErrCommandExited` fatal error is pushed.
+// On shutdown, the command receives a termination request (SIGTERM, or taskkill on windows) and, if it has not exited after termGrace
+// or after the shutdown grace period, it gets killed.
+// The teardown is registered using `Defer`, so the command is stopped before any shutdown callback that was registered earlier.
+func Command(d *Daemon, cmd *exec.Cmd, termGrace time.Duration, opts ...CommandOption) error {
+	cnf := commandConfig{}
+	for _, o := range opts {
+		o(&cnf)
+	}
+
+	if cnf.processGroup {
+		setProcessGroup(cmd)
+	}
+
+	// the reaping loops must not reap the command, its Wait does.
+	reapMutex.RLock()
+	if err := cmd.Start(); err != nil {
+		reapMutex.RUnlock()
+		return err
+	}
+	managedPIDs.Store(cmd.Process.Pid, struct{}{})
+	reapMutex.RUnlock()
+
+	release := func() {}
+	if cnf.processGroup {
+		var err error
+		if release, err = containProcess(cmd); err != nil {
+			d.config.logger.WarnContext(d.ctx, "failed to contain command", slog.String("command", cmd.Path), slog.String("error", err.Error()))
+		}
+	}
+
+	if cnf.forward {
+		d.forwardTo(cmd, func(sig os.Signal) {
+			if len(cnf.forwardSignals) > 0 && !slices.Contains(cnf.forwardSignals, sig) {
+				return
+			}
+			if err := forward(cmd, cnf.processGroup, sig); err != nil {
+				d.config.logger.WarnContext(d.ctx, "failed to forward signal to command",
+					slog.String("command", cmd.Path),
+					slog.String("signal", sig.String()),
+					slog.String("error", err.Error()),
+				)
+			}
+		})
+	}
+
+	stopping := atomic.Bool{}
+	exited := make(chan struct{})
+
+	d.Defer(func(ctx context.Context) {
+		stopping.Store(true)
+
+		select {
+		case <-exited:
+			return
+		default:
+		}
+
+		logger := d.config.logger.With(slog.String("command", cmd.Path), slog.Int("pid", cmd.Process.Pid))
+
+		if err := terminate(cmd, cnf.processGroup); err != nil {
+			logger.WarnContext(ctx, "failed to terminate command", slog.String("error", err.Error()))
+		}
+
+		t := time.NewTimer(termGrace)
+		defer t.Stop()
+
+		select {
+		case <-exited:
+			return
+		case <-t.C:
+		case <-ctx.Done():
+		}
+
+		logger.WarnContext(ctx, "command did not exit in time, killing it")
+		if err := kill(cmd, cnf.processGroup); err != nil {
+			logger.WarnContext(ctx, "failed to kill command", slog.String("error", err.Error()))
+		}
+
+		<-exited
+	})
+
+	go func() {
+		err := cmd.Wait()
+		managedPIDs.Delete(cmd.Process.Pid)
+		d.forwardTo(cmd, nil)
+		release()
+		close(exited)
+
+		if stopping.Load() {
+			return
+		}
+
+		if err != nil {
+			d.pushFatalError(fmt.Errorf("%w: %s: %w", ErrCommandExited, cmd.Path, err))
+		} else {
+			d.pushFatalError(fmt.Errorf("%w: %s", ErrCommandExited, cmd.Path))
+		}
+	}()
+
+	return nil
+}
+
+// forwardTo registers the function that forwards the received signals to the command, or unregisters it if f is nil.
+func (o *Daemon) forwardTo(cmd *exec.Cmd, f func(os.Signal)) {
+	o.forwardsMutex.Lock()
+	defer o.forwardsMutex.Unlock()
+
+	if f == nil {
+		delete(o.forwards, cmd)
+		return
+	}
+
+	if o.forwards == nil {
+		o.forwards = map[*exec.Cmd]func(os.Signal){}
+	}
+	o.forwards[cmd] = f
+}
+
+// forwardSignal forwards the received signal to the commands that have signal forwarding enabled (see `WithSignalForwarding`).
+func (o *Daemon) forwardSignal(sig os.Signal) {
+	o.forwardsMutex.Lock()
+	defer o.forwardsMutex.Unlock()
+
+	for _, f := range o.forwards {
+		f(sig)
+	}
+}
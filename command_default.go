@@ -0,0 +1,29 @@
+//go:build !unix && !windows
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup is not supported on this platform.
+func setProcessGroup(_ *exec.Cmd) {}
+
+// containProcess is not supported on this platform.
+func containProcess(_ *exec.Cmd) (func(), error) { return func() {}, nil }
+
+// terminate asks the command to exit by sending an interrupt.
+func terminate(cmd *exec.Cmd, _ bool) error {
+	return cmd.Process.Signal(os.Interrupt)
+}
+
+// kill terminates the command immediately.
+func kill(cmd *exec.Cmd, _ bool) error {
+	return cmd.Process.Kill()
+}
+
+// forward sends the given signal to the command.
+func forward(cmd *exec.Cmd, _ bool, sig os.Signal) error {
+	return cmd.Process.Signal(sig)
+}
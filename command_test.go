@@ -0,0 +1,105 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommand(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	t.Run("terminated on shutdown", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		// we specifically want a context that will not get cancelled at the end of the test
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		cmd := exec.Command("sh", "-c", "sleep 10")
+		require.NoError(t, Command(d, cmd, time.Second))
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.Equal(t, ReasonManual, d.State().Reason)
+		assert.True(t, cmd.ProcessState.Exited() || !cmd.ProcessState.Success())
+	})
+
+	t.Run("killed after term grace", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		// we specifically want a context that will not get cancelled at the end of the test
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		cmd := exec.Command("sh", "-c", "trap '' TERM; sleep 10 & wait")
+		require.NoError(t, Command(d, cmd, 20*time.Millisecond))
+
+		start := time.Now()
+		d.ShutDown()
+		d.Wait()
+
+		assert.Less(t, time.Since(start), 5*time.Second)
+		assert.False(t, cmd.ProcessState.Success())
+	})
+
+	t.Run("unexpected exit", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		// we specifically want a context that will not get cancelled at the end of the test
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		require.NoError(t, Command(d, exec.Command("sh", "-c", "exit 1"), time.Second))
+
+		d.Wait()
+
+		assert.Equal(t, ReasonFatalError, d.State().Reason)
+	})
+}
+
+func TestCommandProcessGroup(t *testing.T) {
+	if _, err := os.Stat("/proc/self/stat"); err != nil {
+		t.Skip("procfs is not available")
+	}
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	// the shell prints the pid of its child and waits for it.
+	cmd := exec.Command("sh", "-c", "sleep 10 & echo $!; wait")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, Command(d, cmd, time.Second, WithProcessGroup()))
+
+	pid, err := bufio.NewReader(stdout).ReadString('\n')
+	require.NoError(t, err)
+	pid = strings.TrimSpace(pid)
+
+	d.ShutDown()
+	d.Wait()
+
+	// the grandchild should be gone (or a zombie waiting to be reaped).
+	assert.Eventually(t, func() bool {
+		stat, err := os.ReadFile("/proc/" + pid + "/stat")
+		return err != nil || strings.Contains(string(stat), ") Z ")
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,48 @@
+//go:build unix
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// setProcessGroup makes the command the leader of a new process group.
+func setProcessGroup(cmd *exec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	cmd.SysProcAttr.Setpgid = true
+}
+
+// containProcess is a no-op on unix, the process group is set before the command starts (see `setProcessGroup`).
+func containProcess(_ *exec.Cmd) (func(), error) { return func() {}, nil }
+
+// terminate asks the command (or its whole process group) to exit by sending a SIGTERM.
+func terminate(cmd *exec.Cmd, group bool) error {
+	return signalCommand(cmd, group, syscall.SIGTERM)
+}
+
+// kill sends a SIGKILL to the command (or its whole process group).
+func kill(cmd *exec.Cmd, group bool) error {
+	return signalCommand(cmd, group, syscall.SIGKILL)
+}
+
+// forward sends the given signal to the command (or its whole process group).
+func forward(cmd *exec.Cmd, group bool, sig os.Signal) error {
+	if s, ok := sig.(syscall.Signal); ok {
+		return signalCommand(cmd, group, s)
+	}
+
+	return cmd.Process.Signal(sig)
+}
+
+func signalCommand(cmd *exec.Cmd, group bool, sig syscall.Signal) error {
+	if group {
+		// negative pid means the process group.
+		return syscall.Kill(-cmd.Process.Pid, sig)
+	}
+
+	return cmd.Process.Signal(sig)
+}
@@ -0,0 +1,50 @@
+//go:build unix
+
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCommandSignalForwarding(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is not available")
+	}
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// SIGPIPE is ignored by the daemon, but still forwarded.
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), WithSIGPIPEPolicy(SIGPIPEIgnore))
+
+	cmd := exec.Command("sh", "-c", "trap 'echo pipe' PIPE; echo ready; while :; do sleep 0.01; done")
+	stdout, err := cmd.StdoutPipe()
+	require.NoError(t, err)
+	require.NoError(t, Command(d, cmd, time.Second, WithSignalForwarding(syscall.SIGPIPE)))
+
+	r := bufio.NewReader(stdout)
+	line, err := r.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "ready\n", line)
+
+	// not in the forwarded signals.
+	d.forwardSignal(syscall.SIGUSR1)
+	d.signalCh <- syscall.SIGPIPE
+
+	line, err = r.ReadString('\n')
+	require.NoError(t, err)
+	assert.Equal(t, "pipe\n", line)
+
+	d.ShutDown()
+	d.Wait()
+}
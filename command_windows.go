@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                     = syscall.NewLazyDLL("kernel32.dll")
+	procCreateJobObjectW         = kernel32.NewProc("CreateJobObjectW")
+	procSetInformationJobObject  = kernel32.NewProc("SetInformationJobObject")
+	procAssignProcessToJobObject = kernel32.NewProc("AssignProcessToJobObject")
+)
+
+const (
+	jobObjectExtendedLimitInformationClass = 9
+	jobObjectLimitKillOnJobClose           = 0x2000
+	processSetQuota                        = 0x0100
+	processTerminate                       = 0x0001
+)
+
+// jobObjectExtendedLimitInformation is the JOBOBJECT_EXTENDED_LIMIT_INFORMATION structure.
+type jobObjectExtendedLimitInformation struct {
+	BasicLimitInformation struct {
+		PerProcessUserTimeLimit int64
+		PerJobUserTimeLimit     int64
+		LimitFlags              uint32
+		MinimumWorkingSetSize   uintptr
+		MaximumWorkingSetSize   uintptr
+		ActiveProcessLimit      uint32
+		Affinity                uintptr
+		PriorityClass           uint32
+		SchedulingClass         uint32
+	}
+	IoInfo struct {
+		ReadOperationCount  uint64
+		WriteOperationCount uint64
+		OtherOperationCount uint64
+		ReadTransferCount   uint64
+		WriteTransferCount  uint64
+		OtherTransferCount  uint64
+	}
+	ProcessMemoryLimit    uintptr
+	JobMemoryLimit        uintptr
+	PeakProcessMemoryUsed uintptr
+	PeakJobMemoryUsed     uintptr
+}
+
+// setProcessGroup is a no-op on windows, the command is contained in a job object once started (see `containProcess`).
+func setProcessGroup(_ *exec.Cmd) {}
+
+// containProcess places the started command in a new job object with JOB_OBJECT_LIMIT_KILL_ON_JOB_CLOSE, mirroring the unix process group:
+// every descendant of the command is killed once the returned release function closes the job, or once the daemon's process exits, even forcibly.
+// Processes spawned by the command before it is placed in the job are not contained.
+func containProcess(cmd *exec.Cmd) (func(), error) {
+	job, _, err := procCreateJobObjectW.Call(0, 0)
+	if job == 0 {
+		return func() {}, err
+	}
+
+	info := jobObjectExtendedLimitInformation{}
+	info.BasicLimitInformation.LimitFlags = jobObjectLimitKillOnJobClose
+	if ok, _, err := procSetInformationJobObject.Call(job, jobObjectExtendedLimitInformationClass, uintptr(unsafe.Pointer(&info)), unsafe.Sizeof(info)); ok == 0 {
+		_ = syscall.CloseHandle(syscall.Handle(job))
+		return func() {}, err
+	}
+
+	process, err := syscall.OpenProcess(processSetQuota|processTerminate, false, uint32(cmd.Process.Pid)) //nolint:gosec // pids fit in uint32.
+	if err != nil {
+		_ = syscall.CloseHandle(syscall.Handle(job))
+		return func() {}, err
+	}
+	defer syscall.CloseHandle(process) //nolint:errcheck
+
+	if ok, _, err := procAssignProcessToJobObject.Call(job, uintptr(process)); ok == 0 {
+		_ = syscall.CloseHandle(syscall.Handle(job))
+		return func() {}, err
+	}
+
+	return func() { _ = syscall.CloseHandle(syscall.Handle(job)) }, nil
+}
+
+// terminate asks the command (or its whole process tree) to exit using taskkill, since windows has no SIGTERM equivalent.
+func terminate(cmd *exec.Cmd, group bool) error {
+	args := []string{"/PID", strconv.Itoa(cmd.Process.Pid)}
+	if group {
+		args = append(args, "/T")
+	}
+
+	return exec.Command("taskkill", args...).Run()
+}
+
+// kill terminates the command immediately. The rest of the process tree is killed once the command's job object is closed.
+func kill(cmd *exec.Cmd, _ bool) error {
+	return cmd.Process.Kill()
+}
+
+// forward translates the given signal for the command: the interrupt and termination signals become a termination request (see `terminate`),
+// since windows processes can only be sent a kill.
+func forward(cmd *exec.Cmd, group bool, sig os.Signal) error {
+	if sig == os.Interrupt || sig == syscall.SIGTERM {
+		return terminate(cmd, group)
+	}
+
+	return cmd.Process.Signal(sig)
+}
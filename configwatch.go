@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+)
+
+type configWatch struct {
+	path     string
+	onChange func(context.Context) error
+}
+
+// WithConfigWatch periodically checks the config file at path and calls onChange once it changes (its modification time or size).
+// A nil onChange calls `Reload`, and onChange can initiate a restart using `ShutDown`. A failure is logged, or shuts the daemon down with `ErrReloadFailed`
+// if `WithReloadFailureFatal` is used. It can be used multiple times in order to watch more than one file. The watch stops when the shutdown process starts.
+func WithConfigWatch(path string, onChange func(context.Context) error) DaemonConfigOption {
+	return func(oc *config) {
+		oc.configWatches = append(oc.configWatches, configWatch{path: path, onChange: onChange})
+	}
+}
+
+// fileVersion identifies a version of a file by its stat, so the file does not need to be read on every check.
+type fileVersion struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+// equal reports whether v and other describe the same version of the file. The modification times are compared with
+// time.Time.Equal because == also compares the location and the monotonic clock reading.
+func (v fileVersion) equal(other fileVersion) bool {
+	return v.exists == other.exists && v.size == other.size && v.modTime.Equal(other.modTime)
+}
+
+func statFileVersion(path string) (fileVersion, error) {
+	fi, err := os.Stat(path)
+	if os.IsNotExist(err) {
+		return fileVersion{}, nil
+	}
+	if err != nil {
+		return fileVersion{}, err
+	}
+
+	return fileVersion{exists: true, modTime: fi.ModTime(), size: fi.Size()}, nil
+}
+
+// startConfigWatch spawns a go routine per watched file that polls its stat.
+func (o *Daemon) startConfigWatch() {
+	for _, w := range o.config.configWatches {
+		onChange := w.onChange
+		if onChange == nil {
+			onChange = o.Reload
+		}
+
+		last, err := statFileVersion(w.path)
+		if err != nil {
+			o.config.logger.WarnContext(o.ctx, "config watch disabled", slog.String("path", w.path), slog.String("error", err.Error()))
+			continue
+		}
+
+		go o.poll(o.config.configWatchInterval, func() bool {
+			v, err := statFileVersion(w.path)
+			if err != nil {
+				o.config.logger.DebugContext(o.ctx, "failed to stat config file", slog.String("path", w.path), slog.String("error", err.Error()))
+				return false
+			}
+
+			if v.equal(last) {
+				return false
+			}
+			last = v
+
+			if !v.exists {
+				// wait for the file to be created again (e.g. replaced by a rename).
+				o.config.logger.WarnContext(o.ctx, "config file removed", slog.String("path", w.path))
+				return false
+			}
+
+			o.config.logger.InfoContext(o.ctx, "config file changed", slog.String("path", w.path))
+			if err := onChange(o.ctx); err != nil {
+				o.config.logger.ErrorContext(o.ctx, "config change failed", slog.String("path", w.path), slog.String("error", err.Error()))
+				if o.config.reloadFailureFatal {
+					o.pushFatalError(fmt.Errorf("%w: %s: %w", ErrReloadFailed, w.path, err))
+				}
+			}
+
+			return false
+		})
+	}
+}
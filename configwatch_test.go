@@ -0,0 +1,91 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("a: 1"), 0o600))
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	changed := make(chan struct{}, 1)
+	d := Start(
+		context.Background(),
+		WithConfigWatch(path, func(context.Context) error {
+			changed <- struct{}{}
+			return nil
+		}),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		withConfigWatchInterval(5*time.Millisecond),
+	)
+
+	require.NoError(t, os.WriteFile(path, []byte("a: 12"), 0o600))
+	<-changed
+
+	d.ShutDown()
+	d.Wait()
+}
+
+func TestConfigWatchReloadFatal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("a: 1"), 0o600))
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithConfigWatch(path, nil),
+		WithReloadSignal(nil),
+		WithReloadFailureFatal(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		withConfigWatchInterval(5*time.Millisecond),
+	)
+
+	errBadConfig := errors.New("bad config")
+	d.OnReload(func(context.Context) error { return errBadConfig })
+
+	// the file is replaced by a rename, like kubernetes does for config maps.
+	tmp := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(tmp, []byte("a: 12"), 0o600))
+	require.NoError(t, os.Rename(tmp, path))
+
+	d.Wait()
+
+	assert.Equal(t, ReasonFatalError, d.State().Reason)
+	assert.ErrorIs(t, d.ShutdownCause(), ErrReloadFailed)
+	assert.ErrorIs(t, d.ShutdownCause(), errBadConfig)
+}
+
+// withConfigWatchInterval is used only in testing.
+func withConfigWatchInterval(i time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.configWatchInterval = i
+	}
+}
+
+func TestFileVersionEqual(t *testing.T) {
+	now := time.Now()
+	v := fileVersion{exists: true, modTime: now, size: 1}
+
+	assert.True(t, v.equal(fileVersion{exists: true, modTime: now.Round(0).UTC(), size: 1}))
+	assert.False(t, v.equal(fileVersion{exists: true, modTime: now.Add(time.Second), size: 1}))
+	assert.False(t, v.equal(fileVersion{exists: true, modTime: now, size: 2}))
+	assert.False(t, v.equal(fileVersion{}))
+}
@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// WithConsoleReporter prints a concise, human friendly, progress of the shutdown to w (stderr if nil), separately from the logger.
+// It is meant for interactive CLI daemons, so the operator sees what is happening after hitting Ctrl+C.
+// A warning is printed for every shutdown callback that runs longer than slow (zero disables the warning).
+func WithConsoleReporter(w io.Writer, slow time.Duration) DaemonConfigOption {
+	if w == nil {
+		w = os.Stderr
+	}
+
+	return func(oc *config) {
+		oc.observers = append(oc.observers, newConsoleReporter(w, slow).observer())
+	}
+}
+
+type consoleReporter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	slow time.Duration
+	// timers of the slowness warnings of the running callbacks, by position, since they may run concurrently (see `WithShutdownConcurrency`).
+	timers map[int]*time.Timer
+}
+
+func newConsoleReporter(w io.Writer, slow time.Duration) *consoleReporter {
+	return &consoleReporter{w: w, slow: slow, timers: map[int]*time.Timer{}}
+}
+
+func (c *consoleReporter) observer() observer {
+	return observer{
+		shutdownStarted: func(_ context.Context, reason Reason) {
+			c.printf("⏻ shutting down (%s)\n", reason)
+		},
+		callbackStarted: func(_ context.Context, info CallbackInfo) {
+			c.printf("⏳ stopping %s…\n", info.Name)
+			if c.slow <= 0 {
+				return
+			}
+
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			c.timers[info.Position] = time.AfterFunc(c.slow, func() {
+				c.printf("⚠ %s still running after %s\n", info.Name, c.slow)
+			})
+		},
+		callbackFinished: func(_ context.Context, info CallbackInfo, elapsed time.Duration) {
+			c.mu.Lock()
+			if t, found := c.timers[info.Position]; found {
+				t.Stop()
+				delete(c.timers, info.Position)
+			}
+			c.mu.Unlock()
+
+			c.printf("✓ stopped %s (%s)\n", info.Name, elapsed.Round(time.Millisecond))
+		},
+		shutdownFinished: func(_ context.Context, elapsed time.Duration) {
+			c.printf("✔ shutdown completed (%s)\n", elapsed.Round(time.Millisecond))
+		},
+	}
+}
+
+func (c *consoleReporter) printf(format string, a ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, _ = fmt.Fprintf(c.w, format, a...)
+}
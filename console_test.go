@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestConsoleReporter(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	buf := &bytes.Buffer{}
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithConsoleReporter(buf, 20*time.Millisecond),
+	)
+
+	d.Defer(namedModule{}.Stop)
+	d.Defer(func(context.Context) { time.Sleep(100 * time.Millisecond) })
+
+	d.ShutDown()
+	d.Wait()
+
+	out := buf.String()
+	assert.Contains(t, out, "⏻ shutting down (manual)\n")
+	assert.Regexp(t, `⏳ stopping daemon\.TestConsoleReporter\.func1…\n⚠ daemon\.TestConsoleReporter\.func1 still running after 20ms\n✓ stopped daemon\.TestConsoleReporter\.func1 \(\d+ms\)\n`, out)
+	assert.Regexp(t, `⏳ stopping daemon\.namedModule\.Stop…\n✓ stopped daemon\.namedModule\.Stop \(\d+s\)\n`, out)
+	assert.Regexp(t, `✔ shutdown completed \(\d+ms\)\n$`, out)
+}
+
+func TestConsoleReporterConcurrent(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	buf := &lockedBuffer{}
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithShutdownConcurrency(2),
+		WithConsoleReporter(buf, 20*time.Millisecond),
+	)
+
+	slow := func(context.Context) { time.Sleep(100 * time.Millisecond) }
+	d.DeferNamed("first", slow)
+	d.DeferNamed("second", slow)
+
+	d.ShutDown()
+	d.Wait()
+
+	// every running callback gets its own warning, on its own line.
+	out := buf.String()
+	for _, name := range []string{"first", "second"} {
+		assert.Contains(t, out, "⏳ stopping "+name+"…\n")
+		assert.Contains(t, out, "⚠ "+name+" still running after 20ms\n")
+		assert.Regexp(t, `✓ stopped `+name+` \(\d+ms\)\n`, out)
+	}
+}
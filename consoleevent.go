@@ -0,0 +1,10 @@
+package daemon
+
+// WithConsoleCtrlEvents makes, on windows, the close, logoff and shutdown console control events initiate the graceful shutdown,
+// with the grace period capped to 4 seconds, since windows kills the process ~5 seconds after delivering them.
+// The go runtime delivers these events as syscall.SIGTERM, which is added to the notify signals. It has no effect on other platforms.
+func WithConsoleCtrlEvents() DaemonConfigOption {
+	return func(oc *config) {
+		oc.consoleCtrlEvents = true
+	}
+}
@@ -0,0 +1,17 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"os"
+	"time"
+)
+
+// consoleCtrlSignal is nil, console control events are windows only.
+var consoleCtrlSignal os.Signal
+
+// watchConsoleEvents is a no-op, console control events are windows only.
+func watchConsoleEvents() {}
+
+// consoleEvent always returns an empty reason, console control events are windows only.
+func consoleEvent() (Reason, time.Duration) { return "", 0 }
@@ -0,0 +1,21 @@
+package daemon
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConsoleCtrlEvents(t *testing.T) {
+	expected := []os.Signal{os.Interrupt}
+	if consoleCtrlSignal != nil {
+		expected = append(expected, consoleCtrlSignal)
+	}
+
+	c := config{signalsNotify: []os.Signal{os.Interrupt}}
+	WithConsoleCtrlEvents()(&c)
+	assert.Equal(t, expected, c.notifySignals())
+	// the configured signals are not modified.
+	assert.Equal(t, []os.Signal{os.Interrupt}, c.signalsNotify)
+}
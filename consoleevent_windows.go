@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// Console control events, as given to the handlers registered with `SetConsoleCtrlHandler`.
+const (
+	ctrlCEvent        = 0
+	ctrlBreakEvent    = 1
+	ctrlCloseEvent    = 2
+	ctrlLogoffEvent   = 5
+	ctrlShutdownEvent = 6
+)
+
+// consoleCloseGrace caps the shutdown grace period for the close, logoff and shutdown events,
+// since windows kills the process ~5s after delivering them.
+const consoleCloseGrace = 4 * time.Second
+
+// consoleCtrlSignal is the signal the go runtime delivers for the close, logoff and shutdown events.
+var consoleCtrlSignal os.Signal = syscall.SIGTERM
+
+var (
+	consoleEventsOnce sync.Once
+	lastConsoleEvent  atomic.Int64
+)
+
+// watchConsoleEvents registers, once per process, a console control handler that records the last received event.
+// The handler is called before the go runtime's one (handlers are called in reverse registration order) and returns FALSE,
+// so the runtime still translates the event to os.Interrupt or syscall.SIGTERM.
+func watchConsoleEvents() {
+	consoleEventsOnce.Do(func() {
+		lastConsoleEvent.Store(-1)
+		handler := syscall.NewCallback(func(ctrlType uintptr) uintptr {
+			lastConsoleEvent.Store(int64(ctrlType))
+			return 0
+		})
+		proc := syscall.NewLazyDLL("kernel32.dll").NewProc("SetConsoleCtrlHandler")
+		_, _, _ = proc.Call(handler, 1)
+	})
+}
+
+// consoleEvent consumes the last received console event and returns the corresponding shutdown reason
+// and grace period cap. An empty reason is returned if no distinguishable event was received.
+func consoleEvent() (Reason, time.Duration) {
+	return consoleEventReason(lastConsoleEvent.Swap(-1))
+}
+
+func consoleEventReason(event int64) (Reason, time.Duration) {
+	switch event {
+	case ctrlBreakEvent:
+		return ReasonConsoleBreak, 0
+	case ctrlCloseEvent:
+		return ReasonConsoleClose, consoleCloseGrace
+	case ctrlLogoffEvent:
+		return ReasonLogoff, consoleCloseGrace
+	case ctrlShutdownEvent:
+		return ReasonSystemShutdown, consoleCloseGrace
+	default:
+		return "", 0
+	}
+}
@@ -0,0 +1,161 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"net"
+	"os"
+	"sync"
+)
+
+// Control socket commands.
+const (
+	ControlCommandStatus = "status"
+	ControlCommandStop   = "stop"
+	ControlCommandReload = "reload"
+)
+
+var errUnknownControlCommand = errors.New("unknown control command")
+
+// ControlRequest is a request sent to the control socket, encoded as a single JSON object.
+type ControlRequest struct {
+	Command string `json:"command"`
+}
+
+// ControlResponse is the JSON response of the control socket to a `ControlRequest`.
+type ControlResponse struct {
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+	State *State `json:"state,omitempty"`
+}
+
+// WithControlSocket makes the daemon listen on a unix domain socket at the given path for control commands:
+//
+//	status: responds with the daemon's `State`.
+//	stop:   initiates the graceful shutdown.
+//	reload: runs the functions registered using `OnReload`.
+//
+// Each connection can send multiple `ControlRequest` JSON objects and receives a `ControlResponse` for each one.
+// The socket file is only accessible by its owner (mode 0600). The socket is closed once the graceful shutdown is done.
+func WithControlSocket(path string) DaemonConfigOption {
+	return func(oc *config) {
+		oc.controlSocket = path
+	}
+}
+
+type controlServer struct {
+	ln net.Listener
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// startControlSocket starts listening on the control socket, if configured.
+func (o *Daemon) startControlSocket() {
+	path := o.config.controlSocket
+	if path == "" {
+		return
+	}
+
+	// remove a stale socket left behind by a previous run.
+	if fi, err := os.Lstat(path); err == nil && fi.Mode()&fs.ModeSocket != 0 {
+		_ = os.Remove(path)
+	}
+
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to listen on control socket", slog.String("path", path), slog.String("error", err.Error()))
+		return
+	}
+
+	// the socket can stop the daemon, so it is restricted to its owner instead of following the umask.
+	if err := os.Chmod(path, controlSocketMode); err != nil {
+		_ = ln.Close()
+		o.config.logger.ErrorContext(o.ctx, "failed to restrict the control socket", slog.String("path", path), slog.String("error", err.Error()))
+		return
+	}
+
+	o.control = &controlServer{ln: ln, conns: map[net.Conn]struct{}{}}
+
+	o.control.wg.Go(func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+
+			o.control.mu.Lock()
+			o.control.conns[conn] = struct{}{}
+			o.control.mu.Unlock()
+
+			o.control.wg.Go(func() {
+				o.serveControlConn(conn)
+
+				o.control.mu.Lock()
+				delete(o.control.conns, conn)
+				o.control.mu.Unlock()
+			})
+		}
+	})
+}
+
+// stopControlSocket closes the control socket and every open connection, and waits for them to finish.
+func (o *Daemon) stopControlSocket() {
+	if o.control == nil {
+		return
+	}
+
+	_ = o.control.ln.Close()
+
+	o.control.mu.Lock()
+	for c := range o.control.conns {
+		_ = c.Close()
+	}
+	o.control.mu.Unlock()
+
+	o.control.wg.Wait()
+}
+
+func (o *Daemon) serveControlConn(conn net.Conn) {
+	defer func() { _ = conn.Close() }()
+
+	dec := json.NewDecoder(conn)
+	enc := json.NewEncoder(conn)
+
+	for {
+		req := ControlRequest{}
+		if err := dec.Decode(&req); err != nil {
+			return
+		}
+
+		if err := enc.Encode(o.handleControlRequest(req)); err != nil {
+			return
+		}
+	}
+}
+
+func (o *Daemon) handleControlRequest(req ControlRequest) ControlResponse {
+	o.config.logger.InfoContext(o.ctx, "control command received", slog.String("command", req.Command))
+
+	switch req.Command {
+	case ControlCommandStatus:
+		st := o.State()
+		return ControlResponse{OK: true, State: &st}
+
+	case ControlCommandStop:
+		o.ShutDown()
+		return ControlResponse{OK: true}
+
+	case ControlCommandReload:
+		if err := o.Reload(o.ctx); err != nil {
+			return ControlResponse{Error: err.Error()}
+		}
+		return ControlResponse{OK: true}
+
+	default:
+		return ControlResponse{Error: errUnknownControlCommand.Error()}
+	}
+}
@@ -0,0 +1,68 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlSocket(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithControlSocket(path), WithReloadSignal(nil), WithLogger(logger(t)), withSTDAPI(s))
+
+	reloaded := 0
+	d.OnReload(
+		func(context.Context) error { reloaded++; return nil },
+		func(context.Context) error { return errors.New("bad config") },
+	)
+
+	conn, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	enc := json.NewEncoder(conn)
+	dec := json.NewDecoder(conn)
+	send := func(command string) ControlResponse {
+		require.NoError(t, enc.Encode(ControlRequest{Command: command}))
+		resp := ControlResponse{}
+		require.NoError(t, dec.Decode(&resp))
+		return resp
+	}
+
+	resp := send(ControlCommandStatus)
+	assert.True(t, resp.OK)
+	require.NotNil(t, resp.State)
+	assert.Equal(t, StatusRunning, resp.State.Status)
+
+	resp = send(ControlCommandReload)
+	assert.False(t, resp.OK)
+	assert.Equal(t, "bad config", resp.Error)
+	assert.Equal(t, 1, reloaded)
+
+	resp = send("unknown")
+	assert.False(t, resp.OK)
+	assert.Equal(t, errUnknownControlCommand.Error(), resp.Error)
+
+	resp = send(ControlCommandStop)
+	assert.True(t, resp.OK)
+
+	d.Wait()
+
+	assert.Equal(t, ReasonManual, d.State().Reason)
+
+	_, err = net.Dial("unix", path)
+	require.Error(t, err)
+}
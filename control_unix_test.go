@@ -0,0 +1,37 @@
+//go:build unix
+
+package daemon
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestControlSocketMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ctl.sock")
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// a permissive umask does not make the socket accessible to others.
+	umask := syscall.Umask(0)
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithControlSocket(path), WithReloadSignal(nil), WithLogger(logger(t)), withSTDAPI(s))
+	syscall.Umask(umask)
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o600), fi.Mode().Perm())
+
+	d.ShutDown()
+	d.Wait()
+}
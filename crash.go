@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"log/slog"
+	"os"
+	"runtime/debug"
+)
+
+// WithCrashOutput makes unrecovered panics and fatal runtime errors to also write their crash dump to the given file (appended), using `debug.SetCrashOutput`.
+// The file is opened at `Start` and, since the crash output is process wide, it stays in effect after the daemon stops.
+func WithCrashOutput(path string) DaemonConfigOption {
+	return func(oc *config) {
+		oc.crashOutput = path
+	}
+}
+
+// WithTraceback sets, at `Start`, the amount of detail printed by the runtime on unrecovered panics and fatal errors,
+// like the `GOTRACEBACK` environment variable does (e.g. "single", "all", "system", "crash"). See `debug.SetTraceback`.
+// Like `debug.SetTraceback`, it can not set a level lower than the one of the environment variable.
+func WithTraceback(level string) DaemonConfigOption {
+	return func(oc *config) {
+		oc.traceback = level
+	}
+}
+
+// setupCrashOutput applies the crash output and traceback configuration, if any.
+func (o *Daemon) setupCrashOutput() {
+	if o.config.traceback != "" {
+		debug.SetTraceback(o.config.traceback)
+	}
+
+	if o.config.crashOutput == "" {
+		return
+	}
+
+	f, err := os.OpenFile(o.config.crashOutput, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644) //nolint:gosec // the path is given by the configuration.
+	if err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to open crash output file", slog.String("error", err.Error()))
+		return
+	}
+	// SetCrashOutput duplicates the file descriptor, so f can be closed.
+	defer f.Close()
+
+	if err := debug.SetCrashOutput(f, debug.CrashOptions{}); err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to set crash output", slog.String("error", err.Error()))
+	}
+}
@@ -0,0 +1,27 @@
+package daemon
+
+import (
+	"context"
+	"path/filepath"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCrashOutput(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "crash.log")
+	t.Cleanup(func() { _ = debug.SetCrashOutput(nil, debug.CrashOptions{}) })
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithCrashOutput(path), WithTraceback("all"), WithLogger(logger(t)), withSTDAPI(s))
+
+	assert.FileExists(t, path)
+
+	d.ShutDown()
+	d.Wait()
+}
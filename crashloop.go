@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// ErrCrashLoop is the error of the "crash_loop" health check, registered once the daemon detects that it is crash looping (see `WithCrashLoopDetection`).
+var ErrCrashLoop = errors.New("crash loop detected")
+
+// WithCrashLoopDetection marks the daemon as crash looping when, according to the runs recorded in the state file (see `WithStateFile`),
+// more than n runs within the given window did not shut down cleanly (see `Run.Clean`), so routine restarts and deploys do not count.
+// The condition is logged and exposed through `CrashLooping()`, `State()`, the readiness (see `ReadyzHandler`) and a failing "crash_loop" health check.
+func WithCrashLoopDetection(n int, window time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.crashLoopRestarts = n
+		oc.crashLoopWindow = window
+	}
+}
+
+// WithCrashLoopBackoff delays the `Start` of a crash looping daemon (see `WithCrashLoopDetection`).
+// The delay starts from base and doubles for every extra restart in the window, up to maxDelay.
+// The delay happens before the signals are registered, so the process can still be stopped as usual while waiting.
+func WithCrashLoopBackoff(base, maxDelay time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.crashLoopBackoffBase = base
+		oc.crashLoopBackoffMax = maxDelay
+	}
+}
+
+// CrashLooping reports whether the daemon detected at start that it is crash looping.
+func (o *Daemon) CrashLooping() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.crashLoop
+}
+
+// detectCrashLoop counts the recorded unclean runs within the configured window, and backs off if the daemon is crash looping.
+func (o *Daemon) detectCrashLoop() {
+	if o.config.crashLoopWindow <= 0 {
+		return
+	}
+
+	if o.config.stateFile == "" {
+		o.config.logger.WarnContext(o.ctx, "crash loop detection requires a state file")
+		return
+	}
+
+	o.stateFileMutex.Lock()
+	restarts := 0
+	since := o.startedAt.Add(-o.config.crashLoopWindow)
+	// the last run is the current one.
+	for _, r := range o.runs[:max(len(o.runs)-1, 0)] {
+		if r.StartedAt.After(since) && !r.Clean() {
+			restarts++
+		}
+	}
+	o.stateFileMutex.Unlock()
+
+	if restarts <= o.config.crashLoopRestarts {
+		return
+	}
+
+	o.mu.Lock()
+	o.crashLoop = true
+	o.mu.Unlock()
+
+	o.RegisterHealthCheck("crash_loop", func(context.Context) error { return ErrCrashLoop })
+
+	delay := crashLoopBackoff(o.config.crashLoopBackoffBase, o.config.crashLoopBackoffMax, restarts-o.config.crashLoopRestarts-1)
+
+	o.config.logger.ErrorContext(o.ctx, "crash loop detected",
+		slog.Int("restarts", restarts),
+		slog.Duration("window", o.config.crashLoopWindow),
+		slog.Duration("backoff", delay),
+	)
+
+	if delay <= 0 {
+		return
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-o.parentCTX.Done():
+	}
+}
+
+// crashLoopBackoff returns base doubled n times, capped to maxDelay (if set).
+func crashLoopBackoff(base, maxDelay time.Duration, n int) time.Duration {
+	d := base
+	for range n {
+		if maxDelay > 0 && d >= maxDelay {
+			break
+		}
+		d *= 2
+	}
+
+	if maxDelay > 0 && d > maxDelay {
+		return maxDelay
+	}
+
+	return d
+}
@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCrashLoopDetection(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// recent runs that never recorded their end, along with clean ones that do not count.
+	now := time.Now()
+	require.NoError(t, writeStateFile(path, stateFileContent{Runs: []Run{
+		{PID: 1, StartedAt: now.Add(-time.Hour)},
+		{PID: 2, StartedAt: now.Add(-5 * time.Second)},
+		{PID: 3, StartedAt: now.Add(-4 * time.Second), StoppedAt: now.Add(-4 * time.Second)},
+		{PID: 4, StartedAt: now.Add(-3 * time.Second)},
+		{PID: 5, StartedAt: now.Add(-2 * time.Second), StoppedAt: now.Add(-2 * time.Second)},
+		{PID: 6, StartedAt: now.Add(-1 * time.Second), StoppedAt: now.Add(-1 * time.Second), GraceExceeded: true},
+	}}))
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	start := time.Now()
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(
+		context.Background(),
+		WithStateFile(path),
+		WithCrashLoopDetection(2, time.Minute),
+		WithCrashLoopBackoff(20*time.Millisecond, time.Second),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	// 3 unclean runs in window, 1 more than allowed, so the base backoff is applied.
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.True(t, d.CrashLooping())
+	assert.True(t, d.State().CrashLoop)
+
+	rec := httptest.NewRecorder()
+	d.ReadyzHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.ErrorIs(t, d.Health(t.Context())["crash_loop"], ErrCrashLoop)
+
+	d.ShutDown()
+	d.Wait()
+}
+
+func TestCrashLoopBackoff(t *testing.T) {
+	assert.Equal(t, time.Second, crashLoopBackoff(time.Second, time.Minute, 0))
+	assert.Equal(t, 8*time.Second, crashLoopBackoff(time.Second, time.Minute, 3))
+	assert.Equal(t, time.Minute, crashLoopBackoff(time.Second, time.Minute, 100))
+	assert.Equal(t, 4*time.Second, crashLoopBackoff(time.Second, 0, 2))
+	assert.Equal(t, time.Duration(0), crashLoopBackoff(0, time.Minute, 2))
+}
+
+func TestCrashLoopDetectionCleanRuns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	// routine restarts (e.g. deploys) are not a crash loop.
+	now := time.Now()
+	runs := []Run{}
+	for i := range 5 {
+		at := now.Add(-time.Duration(5-i) * time.Second)
+		runs = append(runs, Run{PID: i, StartedAt: at, StoppedAt: at})
+	}
+	require.NoError(t, writeStateFile(path, stateFileContent{Runs: runs}))
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(
+		context.Background(),
+		WithStateFile(path),
+		WithCrashLoopDetection(2, time.Minute),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	assert.False(t, d.CrashLooping())
+	assert.Empty(t, d.Health(t.Context()))
+
+	d.ShutDown()
+	d.Wait()
+}
@@ -2,10 +2,15 @@ package daemon
 
 import (
 	"context"
+	"errors"
+	"io"
 	"log/slog"
 	"os"
+	"os/exec"
+	"runtime/trace"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -16,11 +21,11 @@ const daemonCTXKey = daemonCTXKeyType("daemonCTXKey")
 type OnShutDownCallBack func(context.Context)
 
 // CancelCTX is a shutdown callback that cancels the daemon's context when called.
-// It extracts the daemon instance from the provided context and calls its ctxCancel function.
+// It extracts the daemon instance from the provided context and cancels its context, with a `*ShutdownError` as the cause.
 var CancelCTX OnShutDownCallBack = func(ctx context.Context) {
 	a := ctx.Value(daemonCTXKey)
 	if d, is := a.(*Daemon); is {
-		d.ctxCancel()
+		d.cancelCTX()
 	}
 }
 
@@ -33,6 +38,77 @@ type config struct {
 	logSignal                    func(ctx context.Context, logger *slog.Logger, sig os.Signal)
 	logFatalError                func(ctx context.Context, logger *slog.Logger, err error)
 	stdAPI                       stdAPI
+	maxUptime                    time.Duration
+	maxUptimeJitter              time.Duration
+	idleTimeout                  time.Duration
+	watchdogInterval             time.Duration
+	watchdogAction               WatchdogAction
+	memoryPressureInterval       time.Duration
+	memoryPressureThreshold      float64
+	cgroupDir                    string
+	diskWatches                  []diskWatch
+	diskSpaceWatchInterval       time.Duration
+	controlSocket                string
+	stateFile                    string
+	crashLoopRestarts            int
+	crashLoopWindow              time.Duration
+	crashLoopBackoffBase         time.Duration
+	crashLoopBackoffMax          time.Duration
+	instanceIDFile               string
+	contextCancelPolicy          ContextCancelPolicy
+	observers                    []observer
+	sigpipePolicy                *SIGPIPEPolicy
+	crashOutput                  string
+	traceback                    string
+	resignLeadershipTimeout      time.Duration
+	systemdNotify                bool
+	systemdFDStore               bool
+	graceBudgeting               bool
+	flightRecorderFile           string
+	flightRecorderWindow         time.Duration
+	panicGrace                   time.Duration
+	panicReport                  io.Writer
+	preemptionInterval           time.Duration
+	preemptionChecks             []PreemptionCheck
+	stageBudgets                 [stageCount]time.Duration
+	disarmedStart                bool
+	exitStatusFile               string
+	additionalParents            []context.Context
+	graceWarnings                []float64
+	subreaper                    bool
+	drainDelay                   time.Duration
+	lbDrain                      *lbDrainConfig
+	runtimeBudgetCheck           bool
+	runtimeBudgetClamp           bool
+	runtimeRoot                  string
+	shutdownSLA                  time.Duration
+	shutdownSLAMode              SLAMode
+	signalMeanings               map[os.Signal]signalMeaning
+	shutdownJitter               time.Duration
+	flushBudget                  time.Duration
+	systemdWatchdogCheck         func(context.Context) error
+	systemdExtendInterval        time.Duration
+	consoleCtrlEvents            bool
+	initMode                     bool
+	upgradeSignal                os.Signal
+	upgradeTimeout               time.Duration
+	upgradeCommand               []string
+	shutdownConcurrency          int
+	slowCallbackThreshold        time.Duration
+	exitCodes                    map[Reason]int
+	maxGraceExtension            time.Duration
+	forceExitAfter               time.Duration
+	panicHandler                 func(recovered any, stack []byte)
+	waitRunnersBeforeCallbacks   bool
+	reloadSignal                 os.Signal
+	reloadFailureFatal           bool
+	configWatches                []configWatch
+	configWatchInterval          time.Duration
+	fatalErrorProfilesDir        string
+	adminAddr                    string
+	healthCheckInterval          time.Duration
+	adminShutdown                bool
+	servicePauseContinue         bool
 }
 
 // The Daemon struct encapsulates the core functionality required for running an application as a daemon or service, and it ensures a graceful shutdown when stop conditions are met.
@@ -44,26 +120,126 @@ type config struct {
 //
 // As described in `b` a fatal error channel is returned from the function `FatalErrorsChannel()`, and can be used by the rest of the code when a catastrophic error occurs that needs to trigger an application shutdown.
 type Daemon struct {
-	config config
+	config     config
+	instanceID string
 
 	parentCTX context.Context
 	ctx       context.Context
-	ctxCancel func()
+	ctxCancel context.CancelCauseFunc
 
 	signalCh      chan os.Signal
+	childSignalCh chan os.Signal
 	fatalErrorsCh chan error
 
 	onShutDownMutex sync.Mutex
-	onShutDown      []func(context.Context)
+	onShutDown      []callback
 
 	shutDownOnce sync.Once
 
+	mu                sync.Mutex
+	status            Status
+	startedAt         time.Time
+	reason            Reason
+	cause             error
+	signal            os.Signal
+	lastSignal        os.Signal
+	shutdownStartedAt time.Time
+	shutdownCallbacks []string
+	ttlTimer          *time.Timer
+	scheduleTimer     *time.Timer
+	scheduledShutdown time.Time
+	graceExceeded     bool
+	forced            bool
+	panicked          bool
+	slaExceeded       bool
+	slaPanic          string
+	disarmed          bool
+	pending           *ShutdownInfo
+	callbackErrs      []error
+	graceCap          time.Duration
+	crashLoop         bool
+
+	lastActivity  atomic.Int64
+	lastHeartbeat atomic.Int64
+
+	stagesMutex sync.Mutex
+	stages      [stageCount][]stageHook
+
+	onGraceUsageMutex sync.Mutex
+	onGraceUsage      []func(context.Context, GraceUsage)
+	onShutdownTimeout []func(context.Context, GraceUsage)
+	callbackPosition  atomic.Int64
+	fatalErrors       atomic.Int64
+	skipCallbacks     atomic.Bool
+
+	signalsMutex   sync.Mutex
+	signals        []os.Signal
+	signalsStopped bool
+	onSignal       map[os.Signal][]func(context.Context)
+
+	forwardsMutex sync.Mutex
+	forwards      map[*exec.Cmd]func(os.Signal)
+
+	upgrading atomic.Bool
+	reloading atomic.Bool
+
+	runners runners
+	modules moduleGraph
+
+	flushersMutex sync.Mutex
+	flushers      []Flusher
+
+	onResignMutex sync.Mutex
+	onResign      []func(context.Context) error
+
+	pauseMutex   sync.Mutex
+	onPauseMutex sync.Mutex
+	onPause      []func(context.Context) error
+	onResume     []func(context.Context) error
+
+	onReloadMutex sync.Mutex
+	onReload      []func(context.Context) error
+
+	listenersMutex sync.Mutex
+	listeners      []managedListener
+
+	flightRecorderMutex sync.Mutex
+	flightRecorder      *trace.FlightRecorder
+
+	httpInFlight inFlight
+	rpcInFlight  inFlight
+
+	healthMutex  sync.Mutex
+	healthChecks []*healthCheck
+
+	control *controlServer
+	admin   *adminServer
+
+	stateFileMutex sync.Mutex
+	runs           []Run
+
+	stopping chan struct{}
+
 	done chan struct{}
 }
 
 // CTX returns the cancelable ctx that will get cancel when the daemon initiates it's shutdown process.
 func (o *Daemon) CTX() context.Context { return o.ctx }
 
+// GraceDuration returns the shutdown grace period set using `WithShutdownGraceDuration`. Zero means infinite grace period.
+func (o *Daemon) GraceDuration() time.Duration { return o.config.shutdownTimeout }
+
+// Signals returns the OS signals that are used as stop condition.
+func (o *Daemon) Signals() []os.Signal {
+	o.signalsMutex.Lock()
+	defer o.signalsMutex.Unlock()
+
+	return slices.Clone(o.signals)
+}
+
+// MaxSignalCount returns the number of signals that trigger an immediate termination. Zero means no limit.
+func (o *Daemon) MaxSignalCount() int { return o.config.maxSignalCount }
+
 // Start creates and starts a new daemon with the given parent context and configuration options.
 // It returns a configured daemon instance that manages graceful shutdown based on signals, fatal errors, or parent context cancellation.
 func Start(parentCTX context.Context, opts ...DaemonConfigOption) *Daemon {
@@ -76,31 +252,77 @@ func Start(parentCTX context.Context, opts ...DaemonConfigOption) *Daemon {
 		logSignal:                    logSignal,
 		logFatalError:                logFatalError,
 		stdAPI:                       std{},
+		cgroupDir:                    defaultCgroupDir,
+		runtimeRoot:                  "/",
+		diskSpaceWatchInterval:       defaultDiskSpaceWatchInterval,
+		resignLeadershipTimeout:      defaultResignLeadershipTimeout,
+		panicGrace:                   defaultPanicGrace,
+		panicReport:                  os.Stderr,
+		graceWarnings:                defaultGraceWarnings,
+		flushBudget:                  defaultFlushBudget,
+		systemdExtendInterval:        defaultSystemdExtendInterval,
+		upgradeTimeout:               defaultUpgradeTimeout,
+		slowCallbackThreshold:        defaultSlowCallbackThreshold,
+		reloadSignal:                 defaultReloadSignal,
+		configWatchInterval:          defaultConfigWatchInterval,
 	}
 
 	for _, o := range opts {
 		o(&cnf)
 	}
 
+	instanceID := newInstanceID()
+	cnf.logger = cnf.logger.With(slog.String("instance_id", instanceID))
+
 	signalCh := make(chan os.Signal, cnf.maxSignalCount)
-	cnf.stdAPI.SignalNotify(signalCh, cnf.signalsNotify...)
 
-	ctx, ctxCancel := context.WithCancel(parentCTX)
+	ctx, ctxCancel := context.WithCancelCause(parentCTX)
 	o := &Daemon{
-		config: cnf,
+		config:     cnf,
+		instanceID: instanceID,
 
 		parentCTX: parentCTX,
 		ctx:       ctx,
 		ctxCancel: ctxCancel,
 
 		signalCh:      signalCh,
+		signals:       slices.Clone(cnf.signalsNotify),
 		fatalErrorsCh: make(chan error, cnf.fatalErrorsChannelBufferSize),
 
-		done: make(chan struct{}),
+		status:    StatusRunning,
+		startedAt: time.Now(),
+		disarmed:  cnf.disarmedStart,
+
+		stopping: make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+
+	o.config.observers = append(o.config.observers, o.callbackLogObserver())
+	if cnf.systemdNotify {
+		o.config.observers = append(o.config.observers, o.systemdObserver())
+	}
+	if cnf.shutdownSLA > 0 {
+		o.config.observers = append(o.config.observers, o.slaObserver())
 	}
 
+	_, task := trace.NewTask(parentCTX, "daemon.start")
+	defer task.End()
+
+	o.setupCrashOutput()
+	o.startFlightRecorder()
+	o.writeInstanceIDFile()
+	o.recordRunStart()
+	o.detectCrashLoop()
+	o.resolveDrainDelay()
+	o.checkRuntimeBudget()
+
+	watchConsoleEvents()
+	cnf.stdAPI.SignalNotify(signalCh, cnf.notifySignals()...)
+
 	o.start()
 
+	o.notifySystemdReady()
+
 	return o
 }
 
@@ -112,7 +334,7 @@ func Start(parentCTX context.Context, opts ...DaemonConfigOption) *Daemon {
 func (o *Daemon) OnShutDown(f ...func(context.Context)) {
 	o.onShutDownMutex.Lock()
 	defer o.onShutDownMutex.Unlock()
-	o.onShutDown = append(o.onShutDown, f...)
+	o.onShutDown = append(o.onShutDown, callbacks(f)...)
 }
 
 // Defer pushes the functions to be called on shutdown after the context gets cancelled.
@@ -121,37 +343,188 @@ func (o *Daemon) OnShutDown(f ...func(context.Context)) {
 func (o *Daemon) Defer(f ...func(context.Context)) {
 	o.onShutDownMutex.Lock()
 	defer o.onShutDownMutex.Unlock()
-	o.onShutDown = pushFront(o.onShutDown, f...)
+	o.onShutDown = pushFront(o.onShutDown, callbacks(f)...)
 }
 
 func (o *Daemon) shutDown() {
-	o.config.logger.InfoContext(o.ctx, "starting graceful shutdown")
+	o.mu.Lock()
+	reason := o.reason
+	info := ShutdownInfo{Reason: o.reason, Signal: o.signal, Cause: o.cause}
+	o.shutdownStartedAt = time.Now()
+	// the callbacks mutex is held while the callbacks run, so their names are captured beforehand for the progress reports.
+	o.shutdownCallbacks = o.callbackNames()
+	o.callbackPosition.Store(-1)
+	o.stopTimers()
+	grace := o.shutdownGrace()
+	o.mu.Unlock()
+
+	attrs := []any{slog.String("reason", string(reason))}
+	if info.Cause != nil {
+		attrs = append(attrs, slog.String("cause", info.Cause.Error()))
+	}
+	o.config.logger.InfoContext(o.ctx, "starting graceful shutdown", attrs...)
+	start := time.Now()
+	o.writeFatalErrorProfiles(reason)
+	o.notifyShutdownStarted(o.ctx, reason)
+	o.notifySystemdStopping()
+
+	// add the daemon to ctx in case the CancelCTX shutdown callback is used, and the shutdown info for `ReasonFromContext`.
+	pCTX, task := trace.NewTask(context.WithValue(context.WithValue(o.parentCTX, daemonCTXKey, o), shutdownInfoCTXKey, info), "daemon.shutdown")
+	defer task.End()
+
+	trace.WithRegion(pCTX, "resign_leadership", func() { o.resignLeadership(pCTX) })
+	trace.WithRegion(pCTX, "drain_delay", func() { o.drain(pCTX) })
+	trace.WithRegion(pCTX, "close_listeners", o.closeListeners)
+
+	if o.config.contextCancelPolicy == CancelBeforeCallbacks {
+		o.cancelCTX()
+	}
 
-	// add the daemon to ctx in case the CancelCTX shutdown callback is used.
-	pCTX := context.WithValue(o.parentCTX, daemonCTXKey, o)
+	// the runners are waited for up to the end of the grace period.
+	runnersCTX, runnersCancel := pCTX, func() {}
+	if grace > 0 {
+		runnersCTX, runnersCancel = context.WithTimeout(pCTX, grace)
+	}
 
-	// on shutdown, run every shutdown callback with parent ctx and a separate timeout if configured.
-	if o.config.shutdownTimeout > 0 {
-		dlCTX, dlCancel := context.WithTimeout(pCTX, o.config.shutdownTimeout)
-		runWithMutex(dlCTX, &o.onShutDownMutex, o.onShutDown)
+	// on shutdown, run the drain stages and every shutdown callback with parent ctx and a separate timeout if configured.
+	if grace > 0 {
+		dlCTX, dlCancel := newGraceContext(pCTX, grace, o.config.maxGraceExtension, o.config.logger)
+		stopGraceWarnings := o.startGraceWarnings(dlCTX, grace)
+		disarmForceExit := o.armForceExit(dlCTX)
+		disarmTimeout := o.armShutdownTimeout(dlCTX, grace)
+		o.runStages(dlCTX, reason)
+		o.waitRunnersBeforeCallbacks(dlCTX)
+		trace.WithRegion(dlCTX, "callbacks", func() { o.runCallbacks(dlCTX, reason) })
+		disarmTimeout()
+		disarmForceExit()
+		stopGraceWarnings()
+		if errors.Is(dlCTX.Err(), context.DeadlineExceeded) {
+			o.config.logger.WarnContext(o.ctx, "shutdown grace period exceeded")
+			o.mu.Lock()
+			o.graceExceeded = true
+			o.mu.Unlock()
+			o.dumpFlightRecorder()
+		}
 		dlCancel()
 	} else {
-		runWithMutex(pCTX, &o.onShutDownMutex, o.onShutDown)
+		o.runStages(pCTX, reason)
+		o.waitRunnersBeforeCallbacks(pCTX)
+		trace.WithRegion(pCTX, "callbacks", func() { o.runCallbacks(pCTX, reason) })
 	}
 
 	// cancel ctx
-	o.ctxCancel()
+	if o.config.contextCancelPolicy == CancelAfterCallbacks {
+		o.cancelCTX()
+	}
+
+	o.waitRunners(runnersCTX)
+	runnersCancel()
+
+	o.flush(pCTX)
 
 	o.config.stdAPI.SignalStop(o.signalCh)
+	o.stopWatchingChildren()
+
+	o.stopControlSocket()
+	o.stopAdminServer()
+
+	o.stopFlightRecorder()
+
+	o.mu.Lock()
+	o.status = StatusStopped
+	o.mu.Unlock()
+
+	// the observers run first, so the exit code recorded below accounts for the shutdown SLA.
+	o.notifyShutdownFinished(o.parentCTX, time.Since(start))
+
+	o.recordRunEnd()
+
+	o.writeExitStatus()
 
 	close(o.done)
 
+	// the SLA panic comes last, once the outcome of the shutdown is recorded and the waiters are released.
+	defer o.panicIfSLAExceeded()
+
+	if err := o.Err(); err != nil {
+		o.config.logger.ErrorContext(o.parentCTX, "shutdown completed with errors", slog.String("error", err.Error()))
+		return
+	}
+
 	o.config.logger.InfoContext(o.parentCTX, "shutdown completed")
 }
 
+// cancelCTX cancels the daemon's context with a `*ShutdownError` as the cause, so downstream code can learn why it was stopped using `context.Cause`.
+func (o *Daemon) cancelCTX() {
+	o.mu.Lock()
+	info := ShutdownInfo{Reason: o.reason, Signal: o.signal, Cause: o.cause}
+	o.mu.Unlock()
+
+	o.ctxCancel(&ShutdownError{ShutdownInfo: info})
+}
+
+// stopTimers stops every timer that could trigger a shutdown. It should be called while holding `mu`.
+func (o *Daemon) stopTimers() {
+	if o.ttlTimer != nil {
+		o.ttlTimer.Stop()
+	}
+
+	if o.scheduleTimer != nil {
+		o.scheduleTimer.Stop()
+	}
+}
+
 // ShutDown will initiate the shutdown process (once) in a separate go routine in order to return immediately.
 func (o *Daemon) ShutDown() {
+	o.shutDownWith(ReasonManual, nil)
+}
+
+// ShutDownWithCause is like `ShutDown`, but records why the shutdown happened: the cause is logged, returned by `ShutdownCause` and `WaitErr`,
+// and wrapped by the cause of the context cancellation (see `ShutdownError`). The reason of the shutdown is still `ReasonManual`.
+func (o *Daemon) ShutDownWithCause(err error) {
+	o.shutDownWith(ReasonManual, err)
+}
+
+// ShutDownNow terminates the process immediately, like receiving the max number of signals (see `WithMaxSignalCount`):
+// the remaining shutdown callbacks are skipped, the context is cancelled and the exit function is called with the immediate termination exit code.
+// It is meant for crash-only subsystems that detect an unrecoverable state (e.g. data corruption), where running the rest of the shutdown is not safe.
+func (o *Daemon) ShutDownNow() {
+	o.skipCallbacks.Store(true)
+
+	// like a panic, it initiates the shutdown even if the daemon is not armed yet.
+	o.initiateShutdown(ShutdownInfo{Reason: ReasonManual})
+	o.cancelCTX()
+
+	o.forceExit()
+}
+
+// shutDownWith initiates the shutdown process with the given reason and cause, unless the daemon is not armed yet (see `WithDisarmedStart`),
+// in which case the first trigger is queued until `Arm` is called.
+func (o *Daemon) shutDownWith(reason Reason, cause error) {
+	o.shutDownOn(ShutdownInfo{Reason: reason, Cause: cause})
+}
+
+// shutDownOn is like `shutDownWith`, for a trigger that might also carry the signal received.
+func (o *Daemon) shutDownOn(info ShutdownInfo) {
+	if o.queueTrigger(info) {
+		return
+	}
+
+	o.initiateShutdown(info)
+}
+
+// initiateShutdown records the reason, signal and cause, and initiates the shutdown process (once). Only the first trigger is kept.
+func (o *Daemon) initiateShutdown(info ShutdownInfo) {
 	o.shutDownOnce.Do(func() {
+		o.mu.Lock()
+		o.status = StatusShuttingDown
+		o.reason = info.Reason
+		o.cause = info.Cause
+		o.signal = info.Signal
+		o.mu.Unlock()
+
+		close(o.stopping)
+
 		go o.shutDown()
 	})
 }
@@ -164,6 +537,21 @@ func (o *Daemon) FatalErrorsChannel() chan<- error {
 // start will spawn a go routine that will run until one of the stop conditions is met.
 // After a stop conditions is met the `Daemon` will attempt shutdown "gracefully" by running every function that is registered in `onShutDown` slice, sequentially.
 func (o *Daemon) start() {
+	o.startControlSocket()
+	o.startAdminServer()
+	o.startTTL()
+	o.startIdle()
+	o.startWatchdog()
+	o.startSystemdWatchdog()
+	o.startMemoryPressureWatch()
+	o.startDiskSpaceWatch()
+	o.startConfigWatch()
+	o.startHealthChecks()
+	o.startPreemptionWatch()
+	o.watchAdditionalParents()
+	o.startSubreaper()
+	o.startInitMode()
+
 	go func() {
 		sigReceived := 0
 		// this loop keeps receiving to ensure that any possible send to signalCh and/or fatalErrorsCh will never block.
@@ -171,19 +559,41 @@ func (o *Daemon) start() {
 			select {
 			// Stop condition (A) signal received.
 			case sig := <-o.signalCh:
+				o.mu.Lock()
+				o.lastSignal = sig
+				o.mu.Unlock()
+				o.forwardSignal(sig)
+
+				if handler, found := o.signalHandler(sig); found {
+					handler(o.ctx)
+					continue
+				}
+
 				sigReceived++
 				o.config.logSignal(o.ctx, o.config.logger, sig)
 				if o.config.maxSignalCount > 0 && sigReceived >= o.config.maxSignalCount {
-					o.config.logger.ErrorContext(o.ctx, "max number of signal received, terminating immediately")
-					o.config.stdAPI.OSExit(defaultImmediateTerminationExitCode)
+					o.config.logger.ErrorContext(o.ctx, "max number of signal received")
+					o.forceExit()
 					return
 				}
-				o.ShutDown()
+				info := ShutdownInfo{Reason: ReasonSignal, Signal: sig}
+				if r, graceCap := consoleEvent(); r != "" {
+					info.Reason = r
+					o.mu.Lock()
+					o.graceCap = graceCap
+					o.mu.Unlock()
+				}
+				if m, found := o.config.signalMeanings[sig]; found {
+					info.Reason = Reason(m.label)
+					info.Cause = &SignalError{Signal: sig, Label: m.label}
+				}
+				o.shutDownOn(info)
 
 			// Stop condition (B) fatal error received.
 			case err := <-o.fatalErrorsCh:
+				o.fatalErrors.Add(1)
 				o.config.logFatalError(o.ctx, o.config.logger, err)
-				o.ShutDown()
+				o.shutDownWith(ReasonFatalError, err)
 
 			// stop the loop
 			case <-o.done:
@@ -196,13 +606,13 @@ func (o *Daemon) start() {
 	go func() {
 		select {
 		case <-o.parentCTX.Done():
-			err := o.parentCTX.Err()
-			s := ""
-			if err != nil {
-				s = err.Error()
-			}
-			o.config.logger.ErrorContext(o.ctx, "parent context got canceled", slog.String("error", s))
-			o.ShutDown()
+			// the cause is either the error given to the cancel function of the parent context, or the parent context's error.
+			cause := context.Cause(o.parentCTX)
+			o.config.logger.ErrorContext(o.ctx, "parent context got canceled",
+				slog.String("error", o.parentCTX.Err().Error()),
+				slog.String("cause", cause.Error()),
+			)
+			o.shutDownWith(ReasonParentContext, cause)
 			return
 
 		// stop the loop
@@ -212,10 +622,67 @@ func (o *Daemon) start() {
 	}()
 }
 
+// shutdownGrace returns the configured grace period, capped by the event that initiated the shutdown if any. It should be called while holding `mu`.
+func (o *Daemon) shutdownGrace() time.Duration {
+	if o.graceCap > 0 && (o.config.shutdownTimeout <= 0 || o.config.shutdownTimeout > o.graceCap) {
+		return o.graceCap
+	}
+
+	return o.config.shutdownTimeout
+}
+
+// forceExit terminates the process immediately, without waiting for the graceful shutdown.
+func (o *Daemon) forceExit() {
+	o.config.logger.ErrorContext(o.ctx, "terminating immediately")
+
+	o.mu.Lock()
+	o.forced = true
+	o.mu.Unlock()
+
+	o.recordRunEnd()
+
+	o.dumpFlightRecorder()
+
+	o.writeExitStatus()
+
+	o.config.stdAPI.OSExit(o.exitCode())
+}
+
 func (o *Daemon) Wait() {
 	<-o.done
 }
 
+// WaitErr blocks like `Wait()` and returns the outcome of the shutdown, so main() can tell the stop conditions apart (e.g. to decide the exit code):
+// the cause of the shutdown (the fatal error received, the cause of the parent context, or the `SignalError` of a signal with a meaning, see `WithSignalMeaning`),
+// joined with the errors of the shutdown callbacks (see `Err`). It returns nil for a clean shutdown (e.g. a stop signal or `ShutDown()`).
+func (o *Daemon) WaitErr() error {
+	<-o.done
+
+	o.mu.Lock()
+	cause := o.cause
+	o.mu.Unlock()
+
+	return errors.Join(cause, o.Err())
+}
+
+// WaitContext blocks like `Wait()` until the graceful shutdown is done, or until the given context is done, in which case it returns the context's error.
+func (o *Daemon) WaitContext(ctx context.Context) error {
+	select {
+	case <-o.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// WaitTimeout blocks like `Wait()` for up to the given duration, and returns `context.DeadlineExceeded` if the graceful shutdown is not done by then.
+func (o *Daemon) WaitTimeout(d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+
+	return o.WaitContext(ctx)
+}
+
 type DaemonConfigOption func(*config)
 
 // WithSignalsNotify sets the OS signals that will be used as stop condition to Daemon in order to shutdown gracefully.
@@ -255,17 +722,6 @@ func WithLogger(l *slog.Logger) DaemonConfigOption {
 	}
 }
 
-func runWithMutex(ctx context.Context, m *sync.Mutex, fns []func(context.Context)) {
-	m.Lock()
-	defer m.Unlock()
-	for _, f := range fns {
-		if ctx.Err() != nil {
-			return
-		}
-		f(ctx)
-	}
-}
-
 type stdAPI interface {
 	SignalStop(c chan<- os.Signal)
 	SignalNotify(c chan<- os.Signal, sig ...os.Signal)
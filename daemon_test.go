@@ -5,6 +5,7 @@ import (
 	"errors"
 	"log/slog"
 	"os"
+	"syscall"
 	"testing"
 	"time"
 
@@ -37,7 +38,8 @@ func TestSignalsReceivedTriggerOSExit(t *testing.T) {
 	s := newMockstdAPI(t)
 	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
 	s.EXPECT().SignalStop(mock.Anything).Once()
-	s.EXPECT().OSExit(2).Run(func(code int) { cnl() }).Once()
+	// 128+signum of the last signal received.
+	s.EXPECT().OSExit(128 + int(syscall.SIGINT)).Run(func(code int) { cnl() }).Once()
 
 	d := Start(
 		context.Background(),
@@ -86,6 +88,27 @@ func TestParentContextCancelled(t *testing.T) {
 	d.Wait()
 }
 
+func TestParentContextCause(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	errUpstream := errors.New("upstream framework stopped")
+
+	ctx, cnl := context.WithCancelCause(t.Context())
+	d := Start(ctx, WithLogger(logger(t)), withSTDAPI(s))
+
+	assert.NoError(t, d.ShutdownCause())
+
+	go cnl(errUpstream)
+
+	d.Wait()
+
+	assert.ErrorIs(t, d.ShutdownCause(), errUpstream)
+	assert.Equal(t, ReasonParentContext, d.State().Reason)
+	assert.Equal(t, errUpstream.Error(), d.State().Cause)
+}
+
 func TestShutdownCallbacks(t *testing.T) {
 	s := newMockstdAPI(t)
 	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
@@ -189,6 +212,7 @@ func TestConfigs(t *testing.T) {
 		WithSignalsNotify(os.Interrupt),
 		WithMaxSignalCount(42),
 		WithFatalErrorsChannelBufferSize(100),
+		WithShutdownGraceDuration(5*time.Second),
 		WithLogger(logger(t)),
 		withSTDAPI(s),
 	)
@@ -197,10 +221,87 @@ func TestConfigs(t *testing.T) {
 	assert.Equal(t, 100, cap(d.fatalErrorsCh))
 	assert.Equal(t, 42, d.config.maxSignalCount)
 
+	assert.Equal(t, []os.Signal{os.Interrupt}, d.Signals())
+	assert.Equal(t, 42, d.MaxSignalCount())
+	assert.Equal(t, 5*time.Second, d.GraceDuration())
+
 	d.ShutDown()
 	d.Wait()
 }
 
+func TestWaitContext(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	ctx, cnl := context.WithCancel(t.Context())
+	cnl()
+	assert.ErrorIs(t, d.WaitContext(ctx), context.Canceled)
+	assert.ErrorIs(t, d.WaitTimeout(time.Millisecond), context.DeadlineExceeded)
+
+	d.ShutDown()
+
+	assert.NoError(t, d.WaitContext(t.Context()))
+	assert.NoError(t, d.WaitTimeout(time.Second))
+}
+
+func TestWaitErr(t *testing.T) {
+	errFatal := errors.New("fatal")
+	errCallback := errors.New("callback")
+	errParent := errors.New("parent cancelled")
+
+	tests := map[string]struct {
+		trigger   func(d *Daemon, cancel context.CancelCauseFunc)
+		callback  error
+		expectErr []error
+	}{
+		"clean": {
+			trigger: func(d *Daemon, _ context.CancelCauseFunc) { d.ShutDown() },
+		},
+		"fatal error": {
+			trigger:   func(d *Daemon, _ context.CancelCauseFunc) { d.FatalErrorsChannel() <- errFatal },
+			expectErr: []error{errFatal},
+		},
+		"parent context cause": {
+			trigger:   func(_ *Daemon, cancel context.CancelCauseFunc) { cancel(errParent) },
+			expectErr: []error{errParent},
+		},
+		"callback error": {
+			trigger:   func(d *Daemon, _ context.CancelCauseFunc) { d.FatalErrorsChannel() <- errFatal },
+			callback:  errCallback,
+			expectErr: []error{errFatal, errCallback},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			ctx, cancel := context.WithCancelCause(context.Background())
+			defer cancel(nil)
+
+			d := Start(ctx, WithLogger(logger(t)), withSTDAPI(s))
+			d.OnShutDownE(func(context.Context) error { return tc.callback })
+
+			tc.trigger(d, cancel)
+			err := d.WaitErr()
+
+			if len(tc.expectErr) == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			for _, e := range tc.expectErr {
+				assert.ErrorIs(t, err, e)
+			}
+		})
+	}
+}
+
 func TestWithStandardLibrary(t *testing.T) {
 	d := Start(t.Context())
 	d.ShutDown()
@@ -250,8 +351,8 @@ func TestDefer(t *testing.T) {
 			func(ctx context.Context) { m.MethodCalled("third") },
 		)
 
-		for _, fn := range d.onShutDown {
-			fn(t.Context())
+		for _, cb := range d.onShutDown {
+			cb.fn(t.Context(), CallbackInfo{})
 		}
 	})
 
@@ -279,8 +380,8 @@ func TestDefer(t *testing.T) {
 			func(ctx context.Context) { m.MethodCalled("fifth") },
 		)
 
-		for _, fn := range d.onShutDown {
-			fn(t.Context())
+		for _, cb := range d.onShutDown {
+			cb.fn(t.Context(), CallbackInfo{})
 		}
 	})
 }
@@ -303,8 +404,8 @@ func TestOnShutdown(t *testing.T) {
 			func(ctx context.Context) { m.MethodCalled("third") },
 		)
 
-		for _, fn := range d.onShutDown {
-			fn(t.Context())
+		for _, cb := range d.onShutDown {
+			cb.fn(t.Context(), CallbackInfo{})
 		}
 	})
 
@@ -332,8 +433,8 @@ func TestOnShutdown(t *testing.T) {
 			func(ctx context.Context) { m.MethodCalled("fifth") },
 		)
 
-		for _, fn := range d.onShutDown {
-			fn(t.Context())
+		for _, cb := range d.onShutDown {
+			cb.fn(t.Context(), CallbackInfo{})
 		}
 	})
 }
@@ -461,3 +562,46 @@ func TestPushFront(t *testing.T) {
 		assert.Equal(t, []int{9, 8, 7, 6, 5, 4, 3, 2, 1}, s)
 	})
 }
+
+func TestShutdownGrace(t *testing.T) {
+	tests := map[string]struct {
+		configured time.Duration
+		graceCap   time.Duration
+		expected   time.Duration
+	}{
+		"no cap":             {configured: 10 * time.Second, expected: 10 * time.Second},
+		"cap lower":          {configured: 10 * time.Second, graceCap: 4 * time.Second, expected: 4 * time.Second},
+		"cap higher":         {configured: 2 * time.Second, graceCap: 4 * time.Second, expected: 2 * time.Second},
+		"cap infinite grace": {configured: 0, graceCap: 4 * time.Second, expected: 4 * time.Second},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := &Daemon{config: config{shutdownTimeout: tc.configured}, graceCap: tc.graceCap}
+			assert.Equal(t, tc.expected, d.shutdownGrace())
+		})
+	}
+}
+
+func TestShutDownNow(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+	s.EXPECT().OSExit(2).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	release := make(chan struct{})
+	skipped := true
+	d.Defer(func(context.Context) { skipped = false })
+	d.Defer(func(context.Context) { <-release })
+
+	d.ShutDownNow()
+
+	assert.ErrorIs(t, d.CTX().Err(), context.Canceled)
+	close(release)
+	d.Wait()
+
+	assert.True(t, skipped)
+	assert.Equal(t, 2, d.exitCode())
+}
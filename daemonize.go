@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"os"
+)
+
+// EnvDaemonized is set by `Daemonize` in the environment of the re-executed, detached, process.
+const EnvDaemonized = "DAEMON_DAEMONIZED"
+
+// DaemonizeOption configures `Daemonize`.
+type DaemonizeOption func(*daemonizeConfig)
+
+type daemonizeConfig struct {
+	output  string
+	workDir string
+	exit    func(int)
+	command []string
+}
+
+// WithDaemonizeOutput redirects the standard output and error of the detached process to the file at the given path, in append mode.
+// The default is /dev/null.
+func WithDaemonizeOutput(path string) DaemonizeOption {
+	return func(dc *daemonizeConfig) {
+		dc.output = path
+	}
+}
+
+// WithDaemonizeWorkDir sets the working directory of the detached process. The default is "/", so the process does not keep any mount point busy.
+func WithDaemonizeWorkDir(dir string) DaemonizeOption {
+	return func(dc *daemonizeConfig) {
+		dc.workDir = dir
+	}
+}
+
+// Daemonize runs the process in the background, the traditional unix way. It should be called first in main(), before `Start`.
+// The process re-executes itself, with the same arguments, as the leader of a new session (setsid), detached from the controlling terminal,
+// with the standard input from /dev/null and the standard output and error redirected (see `WithDaemonizeOutput`), and then exits.
+// In the re-executed process, Daemonize returns nil right away, so `Start` proceeds. Relative paths in the arguments are resolved against the
+// working directory of the detached process (see `WithDaemonizeWorkDir`). It returns `errors.ErrUnsupported` on non unix platforms.
+func Daemonize(opts ...DaemonizeOption) error {
+	cnf := daemonizeConfig{
+		output:  os.DevNull,
+		workDir: "/",
+		exit:    os.Exit,
+	}
+	for _, o := range opts {
+		o(&cnf)
+	}
+
+	if os.Getenv(EnvDaemonized) != "" {
+		// the descendants of the detached process should be able to daemonize on their own.
+		return os.Unsetenv(EnvDaemonized)
+	}
+
+	if cnf.command == nil {
+		exe, err := os.Executable()
+		if err != nil {
+			return err
+		}
+		cnf.command = append([]string{exe}, os.Args[1:]...)
+	}
+
+	if err := detach(cnf); err != nil {
+		return err
+	}
+
+	cnf.exit(0)
+
+	return nil
+}
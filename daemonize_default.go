@@ -0,0 +1,10 @@
+//go:build !unix
+
+package daemon
+
+import "errors"
+
+// detach is not supported on this platform.
+func detach(_ daemonizeConfig) error {
+	return errors.ErrUnsupported
+}
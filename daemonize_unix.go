@@ -0,0 +1,40 @@
+//go:build unix
+
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detach starts the command as the leader of a new session, with the standard streams redirected.
+func detach(cnf daemonizeConfig) error {
+	stdin, err := os.Open(os.DevNull)
+	if err != nil {
+		return err
+	}
+	defer stdin.Close()
+
+	out, err := os.OpenFile(cnf.output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o640)
+	if err != nil {
+		return fmt.Errorf("opening daemonize output: %w", err)
+	}
+	defer out.Close()
+
+	cmd := exec.Command(cnf.command[0], cnf.command[1:]...) //nolint:gosec,noctx // the process re-executes itself and outlives the caller.
+	cmd.Env = append(os.Environ(), EnvDaemonized+"=1")
+	cmd.Dir = cnf.workDir
+	cmd.Stdin = stdin
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	// the detached process is not waited for, it gets re-parented once the caller exits.
+	return cmd.Process.Release()
+}
@@ -0,0 +1,72 @@
+//go:build unix
+
+package daemon
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDaemonize(t *testing.T) {
+	t.Run("detached process", func(t *testing.T) {
+		t.Setenv(EnvDaemonized, "1")
+
+		require.NoError(t, Daemonize(func(dc *daemonizeConfig) {
+			dc.exit = func(int) { t.Error("the detached process should not exit") }
+		}))
+		_, found := os.LookupEnv(EnvDaemonized)
+		assert.False(t, found)
+	})
+
+	t.Run("re-executed", func(t *testing.T) {
+		if _, err := exec.LookPath("ps"); err != nil {
+			t.Skip("ps is not available")
+		}
+		t.Setenv(EnvDaemonized, "")
+		require.NoError(t, os.Unsetenv(EnvDaemonized))
+
+		dir := t.TempDir()
+		out := filepath.Join(dir, "out.log")
+
+		exitCode := -1
+		require.NoError(t, Daemonize(
+			WithDaemonizeOutput(out),
+			WithDaemonizeWorkDir(dir),
+			func(dc *daemonizeConfig) {
+				dc.command = []string{"sh", "-c", "echo $DAEMON_DAEMONIZED; pwd; ps -o sid= -p $$ | tr -d ' '; echo $$"}
+				dc.exit = func(code int) { exitCode = code }
+			},
+		))
+		assert.Equal(t, 0, exitCode)
+
+		var lines []string
+		assert.Eventually(t, func() bool {
+			b, _ := os.ReadFile(out)
+			lines = splitLines(string(b))
+			return len(lines) == 4
+		}, 5*time.Second, 10*time.Millisecond)
+
+		assert.Equal(t, "1", lines[0])
+		realDir, err := filepath.EvalSymlinks(dir)
+		require.NoError(t, err)
+		assert.Equal(t, realDir, lines[1])
+		// the session leader.
+		assert.Equal(t, lines[3], lines[2])
+	})
+}
+
+func splitLines(s string) []string {
+	lines := []string{}
+	for l := range strings.Lines(s) {
+		lines = append(lines, strings.TrimSuffix(l, "\n"))
+	}
+
+	return lines
+}
@@ -5,15 +5,39 @@ import (
 	"log/slog"
 	"os"
 	"syscall"
+	"time"
 )
 
 const (
 	defaultMaxSignalCount               = 0
 	defaultFatalErrorsChannelBufferSize = 10
 	defaultShutdownTimeout              = 0
-	defaultImmediateTerminationExitCode = 2
+	defaultForcedExitCode               = 2
+	defaultFatalErrorExitCode           = 1
+	defaultPanicExitCode                = 3
+	defaultSLAExitCode                  = 4
+	defaultPanicGrace                   = 5 * time.Second
+	defaultFlushBudget                  = 2 * time.Second
+	defaultHTTPForceCloseAt             = 0.9
+	defaultMetadataTimeout              = 2 * time.Second
+	defaultReapInterval                 = time.Second
+	defaultStateFileHistory             = 32
+	defaultDiskSpaceWatchInterval       = 10 * time.Second
+	defaultConfigWatchInterval          = 2 * time.Second
+	defaultResignLeadershipTimeout      = 5 * time.Second
+	systemdTimeoutMargin                = time.Second
+	defaultSystemdExtendInterval        = 5 * time.Second
+	defaultUpgradeTimeout               = time.Minute
+	defaultRestartBackoffBase           = 100 * time.Millisecond
+	defaultRestartBackoffMax            = 30 * time.Second
+	defaultSlowCallbackThreshold        = 5 * time.Second
+	dockerStopTimeout                   = 10 * time.Second
+	kubernetesTerminationGracePeriod    = 30 * time.Second
+	controlSocketMode                   = 0o600
 )
 
+var defaultGraceWarnings = []float64{0.5, 0.8}
+
 func logFatalError(ctx context.Context, logger *slog.Logger, err error) {
 	logger.ErrorContext(ctx, "fatal error received", slog.String("error", err.Error()))
 }
@@ -1,4 +1,4 @@
-//go:build !linux
+//go:build !linux && !windows
 
 package daemon
 
@@ -7,3 +7,6 @@ import (
 )
 
 var defaultSignals = []os.Signal{os.Interrupt}
+
+// defaultCgroupDir is empty since cgroups are linux only.
+const defaultCgroupDir = ""
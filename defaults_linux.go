@@ -6,3 +6,6 @@ import (
 )
 
 var defaultSignals = []os.Signal{os.Interrupt, syscall.SIGQUIT, syscall.SIGABRT, syscall.SIGTERM}
+
+// defaultCgroupDir is the cgroup v2 directory of the process, as seen from inside its cgroup namespace.
+const defaultCgroupDir = "/sys/fs/cgroup"
@@ -0,0 +1,12 @@
+package daemon
+
+import (
+	"os"
+)
+
+// defaultSignals are the CTRL_C_EVENT and CTRL_BREAK_EVENT, that the go runtime delivers as os.Interrupt.
+// The close, logoff and shutdown events are opted in using `WithConsoleCtrlEvents`.
+var defaultSignals = []os.Signal{os.Interrupt}
+
+// defaultCgroupDir is empty since cgroups are linux only.
+const defaultCgroupDir = ""
@@ -0,0 +1,57 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+// ErrLowDiskSpace is pushed (wrapped) to the fatal errors channel when a volume watched using `WithDiskSpaceWatch` runs low on free space.
+var ErrLowDiskSpace = errors.New("low disk space")
+
+type diskWatch struct {
+	path    string
+	minFree uint64
+}
+
+// WithDiskSpaceWatch periodically checks the free space (in bytes) of the volume that holds path and pushes an `ErrLowDiskSpace` fatal error once it drops below minFree.
+// It can be used multiple times in order to watch more than one volume. It is only supported on linux; on any other platform it is a no-op.
+func WithDiskSpaceWatch(path string, minFree uint64) DaemonConfigOption {
+	return func(oc *config) {
+		oc.diskWatches = append(oc.diskWatches, diskWatch{path: path, minFree: minFree})
+	}
+}
+
+// startDiskSpaceWatch spawns a go routine per watched volume that polls its free space.
+func (o *Daemon) startDiskSpaceWatch() {
+	for _, w := range o.config.diskWatches {
+		if _, err := diskFreeSpace(w.path); err != nil {
+			o.config.logger.WarnContext(o.ctx, "disk space watch disabled", slog.String("path", w.path), slog.String("error", err.Error()))
+			continue
+		}
+
+		go o.poll(o.config.diskSpaceWatchInterval, func() bool {
+			free, err := diskFreeSpace(w.path)
+			if err != nil {
+				o.config.logger.DebugContext(o.ctx, "failed to read disk free space", slog.String("path", w.path), slog.String("error", err.Error()))
+				return false
+			}
+
+			if free >= w.minFree {
+				return false
+			}
+
+			o.pushFatalError(fmt.Errorf("%w: %s has %d bytes free, minimum is %d", ErrLowDiskSpace, w.path, free, w.minFree))
+
+			return true
+		})
+	}
+}
+
+// pushFatalError pushes the error to the fatal errors channel, unless the shutdown process has already started.
+func (o *Daemon) pushFatalError(err error) {
+	select {
+	case o.fatalErrorsCh <- err:
+	case <-o.stopping:
+	}
+}
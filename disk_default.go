@@ -0,0 +1,10 @@
+//go:build !linux
+
+package daemon
+
+import "errors"
+
+// diskFreeSpace is not supported outside linux.
+func diskFreeSpace(_ string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
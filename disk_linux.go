@@ -0,0 +1,13 @@
+package daemon
+
+import "syscall"
+
+// diskFreeSpace returns the free space in bytes, available to unprivileged users, of the volume that holds path.
+func diskFreeSpace(path string) (uint64, error) {
+	st := syscall.Statfs_t{}
+	if err := syscall.Statfs(path, &st); err != nil {
+		return 0, err
+	}
+
+	return st.Bavail * uint64(st.Bsize), nil //nolint:gosec // block size is never negative.
+}
@@ -0,0 +1,41 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestDiskSpaceWatch(t *testing.T) {
+	if _, err := diskFreeSpace(t.TempDir()); errors.Is(err, errors.ErrUnsupported) {
+		t.Skip("disk space watch is not supported on this platform")
+	}
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(
+		context.Background(),
+		WithDiskSpaceWatch(t.TempDir(), 1<<62), // no volume has that much free space.
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		withDiskSpaceWatchInterval(5*time.Millisecond),
+	)
+
+	d.Wait()
+
+	assert.Equal(t, ReasonFatalError, d.State().Reason)
+}
+
+// withDiskSpaceWatchInterval is used only in testing.
+func withDiskSpaceWatchInterval(i time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.diskSpaceWatchInterval = i
+	}
+}
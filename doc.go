@@ -38,7 +38,8 @@
 // Context:
 // The context provided by the daemon struct .CTX() should be passed downstream to the rest of the code.
 // It will get cancelled by default after the shutdown callbacks are done or if it configured as a shutdown callback
-// by passing daemon.CancelCTX in the Defer() function.
+// by passing daemon.CancelCTX in the Defer() function. The WithContextCancelPolicy option can be used to cancel it
+// before the shutdown callbacks instead, or to leave its cancellation entirely to daemon.CancelCTX.
 //
 // Shutdown callbacks:
 // Using the daemon function Defer(f ...func(context.Context)) you can register callback functions that will be called
@@ -49,4 +50,8 @@
 // Daemon provides an error channel FatalErrorsChannel() chan<- error that can be used downstream to push errors
 // that are considered catastrophic into it. Once an error received in this channel the daemon struct will initiate
 // the graceful shutdown process.
+//
+// Multiple daemons:
+// Every daemon of the process shares a single signal registration. Each daemon receives the signals it is configured for,
+// and the process is terminated only once even if multiple daemons reach their max signal count.
 package daemon
@@ -0,0 +1,173 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"os"
+	"strconv"
+	"time"
+)
+
+// Environment variables read by `WithLBDrainDelay`, for the parameters that are not given as options.
+// The values are either go durations (e.g. `30s`) or integer seconds.
+const (
+	EnvLBDeregistrationDelay = "DAEMON_LB_DEREGISTRATION_DELAY"
+	EnvLBDNSTTL              = "DAEMON_LB_DNS_TTL"
+	EnvTerminationBudget     = "DAEMON_TERMINATION_BUDGET"
+)
+
+// WithDrainDelay delays the drain stages and the shutdown callbacks, after the leadership resignation and before the context cancellation,
+// so the load balancers have time to stop routing new requests to the instance while it still serves them (e.g. after its readiness turns false).
+// The delay is not part of the grace period. Zero (the default) means no delay.
+func WithDrainDelay(d time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.drainDelay = d
+	}
+}
+
+// WithLameDuck is the kubernetes preset of `WithDrainDelay`: once a stop condition is met, the readiness (see `ReadyzHandler`) turns not-ready
+// while the daemon keeps serving for d (lame duck), so the endpoints and the load balancers deregister the pod, and only then the drain stages and the shutdown callbacks run.
+func WithLameDuck(d time.Duration) DaemonConfigOption {
+	return WithDrainDelay(d)
+}
+
+// WithShutdownJitter waits for a random duration up to maxJitter, after the drain delay (see `WithDrainDelay`) and before the drain stages and the shutdown callbacks,
+// so when an orchestrator terminates a whole fleet at once, the instances do not tear down their dependencies (deregistrations, final flushes)
+// at the same time, overwhelming the shared backends. Like the drain delay, it is not part of the grace period. Zero (the default) means no jitter.
+func WithShutdownJitter(maxJitter time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.shutdownJitter = maxJitter
+	}
+}
+
+// LBDrainOption configures the drain delay computed by `WithLBDrainDelay`.
+type LBDrainOption func(*lbDrainConfig)
+
+type lbDrainConfig struct {
+	deregistrationDelay time.Duration
+	dnsTTL              time.Duration
+	terminationBudget   time.Duration
+}
+
+// WithDeregistrationDelay sets the load balancer's deregistration delay (e.g. the ALB target group `deregistration_delay`).
+// If not given, it is read from the `DAEMON_LB_DEREGISTRATION_DELAY` environment variable.
+func WithDeregistrationDelay(d time.Duration) LBDrainOption {
+	return func(lc *lbDrainConfig) {
+		lc.deregistrationDelay = d
+	}
+}
+
+// WithDNSTTL sets the TTL of the DNS records that point to the instance, for the clients that resolve it directly.
+// If not given, it is read from the `DAEMON_LB_DNS_TTL` environment variable.
+func WithDNSTTL(d time.Duration) LBDrainOption {
+	return func(lc *lbDrainConfig) {
+		lc.dnsTTL = d
+	}
+}
+
+// WithTerminationBudget sets the time the platform allows between the termination signal and the kill (e.g. `terminationGracePeriodSeconds`).
+// If not given, it is read from the `DAEMON_TERMINATION_BUDGET` environment variable. Zero means unknown, so the delay is not clamped.
+func WithTerminationBudget(d time.Duration) LBDrainOption {
+	return func(lc *lbDrainConfig) {
+		lc.terminationBudget = d
+	}
+}
+
+// WithLBDrainDelay is like `WithDrainDelay`, with the delay computed from the load balancer's parameters: the deregistration delay plus the DNS TTL.
+// The delay is clamped so that, together with the grace period (see `WithShutdownGraceDuration`), it fits in the platform's termination budget.
+// The computed delay is logged at `Start`.
+func WithLBDrainDelay(opts ...LBDrainOption) DaemonConfigOption {
+	return func(oc *config) {
+		lc := lbDrainConfig{
+			deregistrationDelay: envDuration(EnvLBDeregistrationDelay),
+			dnsTTL:              envDuration(EnvLBDNSTTL),
+			terminationBudget:   envDuration(EnvTerminationBudget),
+		}
+		for _, o := range opts {
+			o(&lc)
+		}
+
+		oc.lbDrain = &lc
+	}
+}
+
+// resolveDrainDelay computes the drain delay from the load balancer's parameters, if configured using `WithLBDrainDelay`.
+func (o *Daemon) resolveDrainDelay() {
+	lc := o.config.lbDrain
+	if lc == nil {
+		return
+	}
+
+	computed := lc.deregistrationDelay + lc.dnsTTL
+	o.config.drainDelay = clampDrainDelay(computed, o.config.shutdownTimeout, lc.terminationBudget)
+
+	o.config.logger.InfoContext(o.ctx, "drain delay computed",
+		slog.Duration("drain_delay", o.config.drainDelay),
+		slog.Duration("deregistration_delay", lc.deregistrationDelay),
+		slog.Duration("dns_ttl", lc.dnsTTL),
+		slog.Duration("termination_budget", lc.terminationBudget),
+	)
+
+	if o.config.drainDelay < computed {
+		o.config.logger.WarnContext(o.ctx, "drain delay clamped to fit in the termination budget along with the grace period",
+			slog.Duration("computed", computed),
+			slog.Duration("grace", o.config.shutdownTimeout),
+		)
+	}
+}
+
+// clampDrainDelay returns the delay that fits in the termination budget along with the grace period. A zero budget means unknown.
+func clampDrainDelay(delay, grace, budget time.Duration) time.Duration {
+	if budget <= 0 {
+		return delay
+	}
+
+	return max(min(delay, budget-grace), 0)
+}
+
+// drain waits for the drain delay and the shutdown jitter, if any.
+func (o *Daemon) drain(ctx context.Context) {
+	wait := o.config.drainDelay
+	if wait > 0 {
+		o.config.logger.InfoContext(o.ctx, "waiting for the load balancers to drain", slog.Duration("drain_delay", wait))
+	}
+
+	if o.config.shutdownJitter > 0 {
+		jitter := time.Duration(rand.Int64N(int64(o.config.shutdownJitter) + 1)) //nolint:gosec // no need for a secure random here.
+		o.config.logger.InfoContext(o.ctx, "delaying the shutdown by a random jitter", slog.Duration("jitter", jitter))
+		wait += jitter
+	}
+
+	if wait <= 0 {
+		return
+	}
+
+	// a forced termination (see `WithMaxSignalCount`) exits the process without waiting.
+	t := time.NewTimer(wait)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// envDuration parses the duration in the given environment variable, either a go duration or integer seconds. It returns zero if unset or invalid.
+func envDuration(key string) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0
+	}
+
+	if secs, err := strconv.Atoi(v); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return 0
+	}
+
+	return d
+}
@@ -0,0 +1,164 @@
+package daemon
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainDelay(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithDrainDelay(100*time.Millisecond),
+	)
+
+	var delayed time.Duration
+	start := time.Now()
+	d.Defer(func(context.Context) { delayed = time.Since(start) })
+
+	d.ShutDown()
+
+	// the context is not cancelled while draining.
+	assert.ErrorIs(t, d.WaitTimeout(50*time.Millisecond), context.DeadlineExceeded)
+	assert.NoError(t, d.CTX().Err())
+
+	d.Wait()
+	assert.GreaterOrEqual(t, delayed, 100*time.Millisecond)
+}
+
+func TestLBDrainDelay(t *testing.T) {
+	t.Setenv(EnvLBDeregistrationDelay, "20")
+	t.Setenv(EnvLBDNSTTL, "5s")
+	t.Setenv(EnvTerminationBudget, "")
+
+	tests := map[string]struct {
+		opts     []LBDrainOption
+		grace    time.Duration
+		expected time.Duration
+	}{
+		"env": {
+			expected: 25 * time.Second,
+		},
+		"options override env": {
+			opts:     []LBDrainOption{WithDeregistrationDelay(10 * time.Second), WithDNSTTL(0)},
+			expected: 10 * time.Second,
+		},
+		"clamped": {
+			opts:     []LBDrainOption{WithTerminationBudget(30 * time.Second)},
+			grace:    10 * time.Second,
+			expected: 20 * time.Second,
+		},
+		"grace exceeds budget": {
+			opts:     []LBDrainOption{WithTerminationBudget(30 * time.Second)},
+			grace:    40 * time.Second,
+			expected: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			d := Start(
+				context.Background(),
+				WithLogger(logger(t)),
+				withSTDAPI(s),
+				WithShutdownGraceDuration(tc.grace),
+				WithLBDrainDelay(tc.opts...),
+			)
+			assert.Equal(t, tc.expected, d.config.drainDelay)
+
+			d.config.drainDelay = 0
+			d.ShutDown()
+			d.Wait()
+		})
+	}
+}
+
+func TestEnvDuration(t *testing.T) {
+	tests := map[string]time.Duration{
+		"":        0,
+		"15":      15 * time.Second,
+		"1m30s":   90 * time.Second,
+		"invalid": 0,
+	}
+
+	for value, expected := range tests {
+		t.Setenv(EnvLBDNSTTL, value)
+		assert.Equal(t, expected, envDuration(EnvLBDNSTTL), value)
+	}
+}
+
+func TestShutdownJitter(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithShutdownJitter(50*time.Millisecond),
+	)
+
+	var delayed time.Duration
+	start := time.Now()
+	d.Defer(func(context.Context) { delayed = time.Since(start) })
+
+	d.ShutDown()
+	d.Wait()
+	assert.Less(t, delayed, 500*time.Millisecond)
+}
+
+func TestLameDuck(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	lameDuck := 50 * time.Millisecond
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithLameDuck(lameDuck),
+	)
+
+	readyz := func() int {
+		rec := httptest.NewRecorder()
+		d.ReadyzHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+		return rec.Code
+	}
+
+	var notReadyAt, callbackAt time.Time
+	d.OnResignLeadership(func(context.Context) error {
+		if readyz() == 503 {
+			notReadyAt = time.Now()
+		}
+		return nil
+	})
+	d.Defer(func(context.Context) {
+		// still serving during the lame duck.
+		assert.NoError(t, d.CTX().Err())
+		callbackAt = time.Now()
+	})
+
+	d.ShutDown()
+	d.Wait()
+
+	require.False(t, notReadyAt.IsZero())
+	assert.GreaterOrEqual(t, callbackAt.Sub(notReadyAt), lameDuck)
+}
@@ -0,0 +1,88 @@
+package daemon
+
+import (
+	"sync"
+)
+
+// ErrGroup is a minimal equivalent of `golang.org/x/sync/errgroup.Group` bound to a daemon, returned by `ErrGroup`.
+// Its context is the daemon's context (`CTX()`), and its first error is pushed to the fatal errors channel in order to shut down the daemon,
+// which in turn cancels the context (see `WithContextCancelPolicy`), like the context of `errgroup.WithContext`.
+type ErrGroup struct {
+	d   *Daemon
+	wg  sync.WaitGroup
+	sem chan struct{}
+
+	errOnce sync.Once
+	err     error
+}
+
+// ErrGroup returns a new group of goroutines, for codebases that structure their workers around errgroup semantics:
+//
+//	g := d.ErrGroup()
+//	g.Go(func() error { return consume(d.CTX()) })
+//	g.Go(func() error { return serve(d.CTX()) })
+//	err := g.Wait()
+func (o *Daemon) ErrGroup() *ErrGroup {
+	return &ErrGroup{d: o}
+}
+
+// SetLimit limits the number of active goroutines in the group to at most n. A negative value indicates no limit.
+// It must not be called while any goroutine of the group is active.
+func (g *ErrGroup) SetLimit(n int) {
+	if n < 0 {
+		g.sem = nil
+		return
+	}
+
+	g.sem = make(chan struct{}, n)
+}
+
+// Go calls f in a new goroutine, blocking until it can be added to the group if the number of active goroutines is limited (see `SetLimit`).
+// The first call to return a non-nil error is pushed to the fatal errors channel and its error is returned by `Wait`.
+func (g *ErrGroup) Go(f func() error) {
+	if g.sem != nil {
+		g.sem <- struct{}{}
+	}
+
+	g.run(f)
+}
+
+// TryGo calls f in a new goroutine only if the number of active goroutines is below the limit (see `SetLimit`), and reports whether it did.
+func (g *ErrGroup) TryGo(f func() error) bool {
+	if g.sem != nil {
+		select {
+		case g.sem <- struct{}{}:
+		default:
+			return false
+		}
+	}
+
+	g.run(f)
+
+	return true
+}
+
+// Wait blocks until all the goroutines of the group have returned, and returns the first non-nil error (if any) from them.
+func (g *ErrGroup) Wait() error {
+	g.wg.Wait()
+
+	return g.err
+}
+
+// run calls f in a new goroutine of the group, which has already acquired its slot if the group is limited.
+func (g *ErrGroup) run(f func() error) {
+	g.wg.Go(func() {
+		defer func() {
+			if g.sem != nil {
+				<-g.sem
+			}
+		}()
+
+		if err := f(); err != nil {
+			g.errOnce.Do(func() {
+				g.err = err
+				g.d.pushFatalError(err)
+			})
+		}
+	})
+}
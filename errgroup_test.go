@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestErrGroup(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), WithContextCancelPolicy(CancelBeforeCallbacks))
+
+	errConsume := errors.New("consume failed")
+	g := d.ErrGroup()
+	g.Go(func() error { return errConsume })
+	g.Go(func() error {
+		<-d.CTX().Done()
+		return d.CTX().Err()
+	})
+
+	assert.ErrorIs(t, g.Wait(), errConsume)
+	d.Wait()
+
+	assert.Equal(t, ReasonFatalError, d.ShutdownReason())
+	assert.ErrorIs(t, d.ShutdownCause(), errConsume)
+}
+
+func TestErrGroupLimit(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	g := d.ErrGroup()
+	g.SetLimit(1)
+
+	release := make(chan struct{})
+	g.Go(func() error {
+		<-release
+		return nil
+	})
+	assert.False(t, g.TryGo(func() error { return nil }))
+	close(release)
+
+	ran := atomic.Bool{}
+	g.Go(func() error {
+		ran.Store(true)
+		return nil
+	})
+	assert.NoError(t, g.Wait())
+	assert.True(t, ran.Load())
+
+	d.ShutDown()
+	d.Wait()
+}
@@ -2,8 +2,6 @@ package main
 
 import (
 	"context"
-	"errors"
-	"net"
 	"net/http"
 	"time"
 
@@ -13,44 +11,16 @@ import (
 func main() {
 	d := daemon.Start(context.Background())
 
-	ctx := d.CTX() // This ctx should be provided to the rest of the code
+	// BaseContext is wired to d.CTX(), Serve runs in a go routine tracked by the daemon (an error shuts it down) and Shutdown is called on shutdown.
+	d.Register(daemon.HTTPServer(d, "http", &http.Server{
+		Addr:              "0.0.0.0:3030",
+		Handler:           http.DefaultServeMux,
+		ReadHeaderTimeout: 3 * time.Second,
+	}))
 
-	httpServer := NewHTTPModule(ctx)
-	httpServer.Start(d.FatalErrorsChannel()) // starts its own go routine
-
-	d.Defer(
-		httpServer.ShutDown,
-	)
+	if err := d.StartModules(); err != nil {
+		d.ShutDownWithCause(err)
+	}
 
 	d.Wait()
 }
-
-type httpModule struct {
-	server *http.Server
-}
-
-func (s *httpModule) Start(fatalErrors chan<- error) {
-	go func() {
-		err := s.server.ListenAndServe()
-		if err != nil && !errors.Is(err, http.ErrServerClosed) {
-			fatalErrors <- err
-		}
-	}()
-}
-
-func (s *httpModule) ShutDown(ctx context.Context) {
-	_ = s.server.Shutdown(ctx)
-}
-
-func NewHTTPModule(ctx context.Context) *httpModule {
-	return &httpModule{
-		server: &http.Server{
-			Addr:              "0.0.0.0:3030",
-			Handler:           http.DefaultServeMux,
-			ReadHeaderTimeout: 3 * time.Second,
-			BaseContext: func(_ net.Listener) context.Context {
-				return ctx
-			},
-		},
-	}
-}
@@ -0,0 +1,85 @@
+package daemon
+
+import (
+	"os"
+	"syscall"
+)
+
+// WithExitCodes sets the exit code (see `WaitExitCode`) of a shutdown initiated by the given reasons, e.g. `map[Reason]int{ReasonWatchdog: 70}`.
+// The label of a signal with a meaning (see `WithSignalMeaning`) is the reason of the shutdown it initiates, so it can be used as well.
+// They take precedence over the default exit codes and the shutdown SLA (see `WithShutdownSLA`). The exit code of the immediate termination
+// can be set using `ReasonImmediateTermination`, which takes precedence over the reason of the shutdown that was cut short.
+func WithExitCodes(codes map[Reason]int) DaemonConfigOption {
+	return func(oc *config) {
+		if oc.exitCodes == nil {
+			oc.exitCodes = make(map[Reason]int, len(codes))
+		}
+		for r, c := range codes {
+			oc.exitCodes[r] = c
+		}
+	}
+}
+
+// ReasonImmediateTermination is not a stop condition: it is the key of the exit code of an immediate termination (see `WithMaxSignalCount`,
+// `ShutDownNow` and `WithForceExitAfter`) in `WithExitCodes`.
+const ReasonImmediateTermination Reason = "immediate_termination"
+
+// WithSignalExitCodes used to make the immediate termination exit with 128+signum of the last signal received.
+//
+// Deprecated: 128+signum is the default exit code of the immediate termination, so it has no effect.
+func WithSignalExitCodes() DaemonConfigOption {
+	return func(*config) {}
+}
+
+// WaitExitCode blocks like `Wait()` and then returns the exit code that corresponds to the way the daemon stopped.
+// It can be used to terminate main() like `os.Exit(d.WaitExitCode())`.
+func (o *Daemon) WaitExitCode() int {
+	o.Wait()
+
+	return o.exitCode()
+}
+
+// exitCode returns the exit code that corresponds to the way the daemon stopped:
+// immediate termination (128+signum of the last signal received, following the shell convention for processes terminated by a signal,
+// or 2 if no signal was received, e.g. `ShutDownNow`), shutdown with a reason that has an exit code configured using `WithExitCodes`, shutdown because of a panic or a fatal error, shutdown SLA exceeded (see `WithShutdownSLA`),
+// shutdown initiated by a signal with a meaning (see `WithSignalMeaning`), or graceful shutdown.
+func (o *Daemon) exitCode() int {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	meaning, hasMeaning := o.config.signalMeanings[o.signal]
+	reasonCode, hasReasonCode := o.config.exitCodes[o.reason]
+
+	switch {
+	case o.forced:
+		if code, ok := o.config.exitCodes[ReasonImmediateTermination]; ok {
+			return code
+		}
+		if code, ok := signalExitCode(o.lastSignal); ok {
+			return code
+		}
+		return defaultForcedExitCode
+	case hasReasonCode:
+		return reasonCode
+	case o.panicked:
+		return defaultPanicExitCode
+	case o.reason == ReasonFatalError:
+		return defaultFatalErrorExitCode
+	case o.slaExceeded:
+		return defaultSLAExitCode
+	case hasMeaning:
+		return meaning.exitCode
+	default:
+		return 0
+	}
+}
+
+// signalExitCode returns 128+signum for the given signal, if it has a number.
+func signalExitCode(sig os.Signal) (int, bool) {
+	s, ok := sig.(syscall.Signal)
+	if !ok {
+		return 0, false
+	}
+
+	return 128 + int(s), true
+}
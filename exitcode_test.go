@@ -0,0 +1,93 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWaitExitCode(t *testing.T) {
+	tests := map[string]struct {
+		opts     []DaemonConfigOption
+		stop     func(d *Daemon)
+		expected int
+	}{
+		"manual": {
+			stop:     func(d *Daemon) { d.ShutDown() },
+			expected: 0,
+		},
+		"fatal error": {
+			stop:     func(d *Daemon) { d.FatalErrorsChannel() <- errors.New("error") },
+			expected: 1,
+		},
+		"configured reason": {
+			opts:     []DaemonConfigOption{WithExitCodes(map[Reason]int{ReasonFatalError: 70, ReasonManual: 64})},
+			stop:     func(d *Daemon) { d.FatalErrorsChannel() <- errors.New("error") },
+			expected: 70,
+		},
+		"configured signal meaning label": {
+			opts: []DaemonConfigOption{
+				WithSignalMeaning(os.Interrupt, "operator", 130),
+				WithExitCodes(map[Reason]int{ReasonSignal: 75, Reason("operator"): 76}),
+			},
+			stop:     func(d *Daemon) { d.signalCh <- os.Interrupt },
+			expected: 76,
+		},
+		"not configured reason": {
+			opts:     []DaemonConfigOption{WithExitCodes(map[Reason]int{ReasonFatalError: 70})},
+			stop:     func(d *Daemon) { d.ShutDown() },
+			expected: 0,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			// we specifically want a context that will not get cancelled at the end of the test
+			d := Start(context.Background(), append(tc.opts, WithLogger(logger(t)), withSTDAPI(s))...)
+			tc.stop(d)
+
+			assert.Equal(t, tc.expected, d.WaitExitCode())
+		})
+	}
+}
+
+func TestSignalExitCode(t *testing.T) {
+	code, ok := signalExitCode(syscall.SIGTERM)
+	assert.True(t, ok)
+	assert.Equal(t, 128+int(syscall.SIGTERM), code)
+
+	_, ok = signalExitCode(nil)
+	assert.False(t, ok)
+}
+
+func TestForcedExitCode(t *testing.T) {
+	tests := map[string]struct {
+		lastSignal os.Signal
+		exitCodes  map[Reason]int
+		expected   int
+	}{
+		"signal":    {lastSignal: syscall.SIGTERM, expected: 128 + int(syscall.SIGTERM)},
+		"no signal": {expected: defaultForcedExitCode},
+		"configured": {
+			lastSignal: syscall.SIGTERM,
+			exitCodes:  map[Reason]int{ReasonImmediateTermination: 70, ReasonSignal: 75},
+			expected:   70,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			d := &Daemon{config: config{exitCodes: tc.exitCodes}, forced: true, lastSignal: tc.lastSignal, reason: ReasonSignal}
+			assert.Equal(t, tc.expected, d.exitCode())
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package daemon
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"time"
+)
+
+// ExitStatus is the content of the exit status file (see `WithExitStatusFile`).
+type ExitStatus struct {
+	InstanceID string    `json:"instance_id"`
+	PID        int       `json:"pid"`
+	Reason     Reason    `json:"reason"`
+	Cause      string    `json:"cause,omitempty"`
+	ExitCode   int       `json:"exit_code"`
+	Clean      bool      `json:"clean"`
+	Timestamp  time.Time `json:"timestamp"`
+}
+
+// WithExitStatusFile makes the daemon write (atomically) a small JSON file, described by `ExitStatus`, as its last act:
+// once the shutdown is done or right before an immediate termination. External supervisors can use it to decide their restart or alerting behavior.
+// The status is clean if the graceful shutdown completed in time.
+func WithExitStatusFile(path string) DaemonConfigOption {
+	return func(oc *config) {
+		oc.exitStatusFile = path
+	}
+}
+
+// writeExitStatus writes the exit status file, if configured.
+func (o *Daemon) writeExitStatus() {
+	if o.config.exitStatusFile == "" {
+		return
+	}
+
+	code := o.exitCode()
+
+	o.mu.Lock()
+	st := ExitStatus{
+		InstanceID: o.instanceID,
+		PID:        os.Getpid(),
+		Reason:     o.reason,
+		ExitCode:   code,
+		Clean:      !o.forced && !o.graceExceeded,
+		Timestamp:  time.Now(),
+	}
+	if o.cause != nil {
+		st.Cause = o.cause.Error()
+	}
+	o.mu.Unlock()
+
+	b, err := json.Marshal(st)
+	if err == nil {
+		err = writeFileAtomic(o.config.exitStatusFile, b)
+	}
+	if err != nil {
+		o.config.logger.ErrorContext(o.parentCTX, "failed to write exit status file", slog.String("error", err.Error()))
+	}
+}
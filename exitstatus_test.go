@@ -0,0 +1,41 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExitStatusFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "exit.json")
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithExitStatusFile(path), WithLogger(logger(t)), withSTDAPI(s))
+
+	d.FatalErrorsChannel() <- errors.New("disk is gone")
+	d.Wait()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	st := ExitStatus{}
+	require.NoError(t, json.Unmarshal(b, &st))
+	assert.Equal(t, d.InstanceID(), st.InstanceID)
+	assert.Equal(t, os.Getpid(), st.PID)
+	assert.Equal(t, ReasonFatalError, st.Reason)
+	assert.Equal(t, "disk is gone", st.Cause)
+	assert.Equal(t, 1, st.ExitCode)
+	assert.True(t, st.Clean)
+	assert.WithinDuration(t, time.Now(), st.Timestamp, 5*time.Second)
+}
@@ -0,0 +1,193 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type graceCTXKeyType string
+
+const graceCTXKey = graceCTXKeyType("graceCTXKey")
+
+// WithMaxGraceExtension sets how much the shutdown grace period can be extended in total using `ExtendGrace`.
+// Zero (the default) means the grace period can not be extended.
+func WithMaxGraceExtension(d time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.maxGraceExtension = d
+	}
+}
+
+// ExtendGrace pushes out the deadline of the shutdown grace period by d, when called from within a shutdown callback (or a drain stage) with the context it received,
+// for cases like "still flushing a 2GB buffer". The extensions are bounded in total by the maximum set using `WithMaxGraceExtension`.
+// It returns the extension that was granted, which is zero if ctx is not a shutdown context, the grace period is infinite or already exceeded, or the maximum is reached.
+// The deadline of a callback with its own budget (see `WithGraceBudgeting`) is not extended, only the one of the whole grace period.
+func ExtendGrace(ctx context.Context, d time.Duration) time.Duration {
+	g, ok := ctx.Value(graceCTXKey).(*graceContext)
+	if !ok {
+		return 0
+	}
+
+	return g.extend(d)
+}
+
+// graceContext is the context of the shutdown grace period. Unlike a context created using `context.WithTimeout`, its deadline can be pushed out.
+// It is backed by a context created using `context.WithDeadlineCause`, re-derived from the parent on each extension. It owns its done channel and
+// implements `AfterFunc`, so the contexts derived from it are cancelled with its error, `context.DeadlineExceeded` once the grace period is exceeded,
+// instead of being bound to the inner context that gets replaced.
+type graceContext struct {
+	parent context.Context
+	logger *slog.Logger
+
+	mu         sync.Mutex
+	deadline   time.Time
+	limit      time.Time
+	inner      context.Context
+	cancel     context.CancelFunc
+	stopInner  func() bool
+	done       chan struct{}
+	err        error
+	afterFuncs map[uint64]func()
+	nextFunc   uint64
+}
+
+// newGraceContext returns a context that is done once the grace period is exceeded, and that can be extended up to maxExtension.
+// The returned function releases its resources.
+func newGraceContext(parent context.Context, grace, maxExtension time.Duration, logger *slog.Logger) (*graceContext, func()) {
+	now := time.Now()
+
+	g := &graceContext{
+		parent:     parent,
+		logger:     logger,
+		deadline:   now.Add(grace),
+		limit:      now.Add(grace + max(maxExtension, 0)),
+		done:       make(chan struct{}),
+		afterFuncs: map[uint64]func(){},
+	}
+	g.derive()
+
+	return g, func() {
+		g.mu.Lock()
+		cancel := g.cancel
+		g.mu.Unlock()
+		cancel()
+	}
+}
+
+// derive replaces the inner context with one having the current deadline. It should be called while holding `mu`, or before g is shared.
+func (g *graceContext) derive() {
+	inner, cancel := context.WithDeadlineCause(g.parent, g.deadline, context.DeadlineExceeded)
+	g.inner, g.cancel = inner, cancel
+	g.stopInner = context.AfterFunc(inner, func() { g.finish(inner) })
+}
+
+// finish marks g as done with the error of inner, unless inner got replaced meanwhile.
+func (g *graceContext) finish(inner context.Context) {
+	g.mu.Lock()
+	if g.inner != inner || g.err != nil {
+		g.mu.Unlock()
+		return
+	}
+
+	g.err = inner.Err()
+	close(g.done)
+	funcs := g.afterFuncs
+	g.afterFuncs = nil
+	g.mu.Unlock()
+
+	// like the children of a cancelCtx, they are called right away: the context package passes functions that cancel the derived contexts without blocking.
+	for _, f := range funcs {
+		f()
+	}
+}
+
+func (g *graceContext) Deadline() (time.Time, bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.deadline, true
+}
+
+func (g *graceContext) Done() <-chan struct{} {
+	return g.done
+}
+
+// Err returns `context.DeadlineExceeded` once the grace period is exceeded, like a context created using `context.WithTimeout`.
+func (g *graceContext) Err() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.err
+}
+
+func (g *graceContext) Value(key any) any {
+	if key == graceCTXKey {
+		return g
+	}
+
+	return g.parent.Value(key)
+}
+
+// AfterFunc arranges to call f once g is done. The context package uses it to cancel the contexts derived from g with g's error,
+// so f must not block.
+func (g *graceContext) AfterFunc(f func()) func() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.err != nil {
+		// f may call Err, which needs mu.
+		go f()
+		return func() bool { return false }
+	}
+
+	id := g.nextFunc
+	g.nextFunc++
+	g.afterFuncs[id] = f
+
+	return func() bool {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+
+		_, found := g.afterFuncs[id]
+		delete(g.afterFuncs, id)
+
+		return found
+	}
+}
+
+// extend pushes out the deadline by d, up to the limit, and returns the extension granted.
+func (g *graceContext) extend(d time.Duration) time.Duration {
+	granted, deadline := g.pushDeadline(d)
+	if granted > 0 {
+		g.logger.InfoContext(g, "shutdown grace period extended", slog.Duration("extension", granted), slog.Time("deadline", deadline))
+	}
+
+	return granted
+}
+
+func (g *graceContext) pushDeadline(d time.Duration) (time.Duration, time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if d <= 0 || g.err != nil || !g.deadline.Before(g.limit) {
+		return 0, g.deadline
+	}
+
+	deadline := g.deadline.Add(d)
+	if deadline.After(g.limit) {
+		deadline = g.limit
+	}
+	if !g.stopInner() {
+		// the grace period got exceeded meanwhile.
+		return 0, g.deadline
+	}
+
+	granted := deadline.Sub(g.deadline)
+	g.deadline = deadline
+	prevCancel := g.cancel
+	g.derive()
+	prevCancel()
+
+	return granted, deadline
+}
@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExtendGrace(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithShutdownGraceDuration(50*time.Millisecond),
+		WithMaxGraceExtension(100*time.Millisecond),
+	)
+
+	var (
+		granted, exhausted time.Duration
+		errAfterExtension  error
+		errAtLimit         error
+	)
+	d.Defer(func(ctx context.Context) {
+		before, _ := ctx.Deadline()
+		granted = ExtendGrace(ctx, time.Minute)
+		after, _ := ctx.Deadline()
+		assert.Equal(t, granted, after.Sub(before))
+
+		// past the original grace period.
+		time.Sleep(80 * time.Millisecond)
+		errAfterExtension = ctx.Err()
+
+		exhausted = ExtendGrace(ctx, time.Second)
+		<-ctx.Done()
+		errAtLimit = ctx.Err()
+	})
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.Equal(t, 100*time.Millisecond, granted)
+	assert.Zero(t, exhausted)
+	assert.NoError(t, errAfterExtension)
+	assert.ErrorIs(t, errAtLimit, context.DeadlineExceeded)
+	assert.True(t, d.graceExceeded)
+}
+
+func TestExtendGraceNotShutdownContext(t *testing.T) {
+	assert.Zero(t, ExtendGrace(context.Background(), time.Second))
+}
+
+func TestGraceContextDerived(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithShutdownGraceDuration(30*time.Millisecond),
+		WithMaxGraceExtension(30*time.Millisecond),
+	)
+
+	var (
+		errAfterExtension, errDerived, errWithCancel error
+	)
+	d.Defer(func(ctx context.Context) {
+		// derived before the extension.
+		derived, cancel := context.WithTimeout(ctx, time.Hour)
+		defer cancel()
+		withCancel, cancelWithCancel := context.WithCancel(ctx)
+		defer cancelWithCancel()
+
+		ExtendGrace(ctx, time.Minute)
+
+		// past the original grace period.
+		time.Sleep(40 * time.Millisecond)
+		errAfterExtension = derived.Err()
+
+		<-derived.Done()
+		<-withCancel.Done()
+		errDerived, errWithCancel = derived.Err(), withCancel.Err()
+	})
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.NoError(t, errAfterExtension)
+	assert.ErrorIs(t, errDerived, context.DeadlineExceeded)
+	assert.ErrorIs(t, errWithCancel, context.DeadlineExceeded)
+}
+
+func TestGraceContextStage(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithShutdownGraceDuration(20*time.Millisecond),
+	)
+
+	var errStage, errDerived error
+	d.OnStage(StageFlush, func(ctx context.Context) error {
+		derived, cancel := context.WithTimeout(ctx, time.Hour)
+		defer cancel()
+		<-derived.Done()
+		errStage, errDerived = ctx.Err(), derived.Err()
+		return nil
+	})
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.ErrorIs(t, errStage, context.DeadlineExceeded)
+	assert.ErrorIs(t, errDerived, context.DeadlineExceeded)
+}
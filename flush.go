@@ -0,0 +1,64 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+// Flusher flushes buffered telemetry, e.g. metrics, logs or trace exporters.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// FlusherFunc is an adapter to allow the use of ordinary functions as a `Flusher`.
+type FlusherFunc func(ctx context.Context) error
+
+// Flush calls f(ctx).
+func (f FlusherFunc) Flush(ctx context.Context) error {
+	return f(ctx)
+}
+
+// WithFlushBudget sets the time budget of the flushers (see `RegisterFlusher`). The default is 2 seconds.
+func WithFlushBudget(d time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.flushBudget = d
+	}
+}
+
+// RegisterFlusher registers flushers to be called in a dedicated final stage of the shutdown, after every shutdown callback.
+// The flushers run concurrently, with their own budget (see `WithFlushBudget`) that is reserved on top of the grace period,
+// so the last batch of telemetry is not lost even when the grace period was exceeded. Errors are logged.
+func (o *Daemon) RegisterFlusher(f ...Flusher) {
+	o.flushersMutex.Lock()
+	defer o.flushersMutex.Unlock()
+	o.flushers = append(o.flushers, f...)
+}
+
+// flush runs every flusher within the flush budget. The given ctx's cancellation and deadline are not inherited.
+func (o *Daemon) flush(ctx context.Context) {
+	o.flushersMutex.Lock()
+	flushers := o.flushers
+	o.flushersMutex.Unlock()
+
+	if len(flushers) == 0 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), o.config.flushBudget)
+	defer cancel()
+
+	trace.WithRegion(ctx, "flush", func() {
+		wg := sync.WaitGroup{}
+		for _, f := range flushers {
+			wg.Go(func() {
+				if err := f.Flush(ctx); err != nil {
+					o.config.logger.ErrorContext(o.ctx, "flusher failed", slog.String("error", err.Error()))
+				}
+			})
+		}
+		wg.Wait()
+	})
+}
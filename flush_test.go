@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRegisterFlusher(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithShutdownGraceDuration(20*time.Millisecond),
+		WithFlushBudget(time.Second),
+	)
+
+	// the callback exceeds the grace period.
+	d.Defer(func(ctx context.Context) { <-ctx.Done() })
+
+	f := NewMockFlusher(t)
+	f.EXPECT().Flush(mock.Anything).RunAndReturn(func(ctx context.Context) error {
+		// the flushers get their own budget.
+		assert.NoError(t, ctx.Err())
+		deadline, ok := ctx.Deadline()
+		assert.True(t, ok)
+		assert.Greater(t, time.Until(deadline), 500*time.Millisecond)
+
+		return nil
+	}).Once()
+
+	flushed := false
+	d.RegisterFlusher(f, FlusherFunc(func(context.Context) error {
+		flushed = true
+		return errors.New("error")
+	}))
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.True(t, flushed)
+	assert.True(t, d.graceExceeded)
+}
@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+// WithForceExitAfter terminates the process if the shutdown callbacks are still running d after the grace period is exceeded,
+// e.g. because a callback ignores the cancellation of its context and would otherwise hang the process forever.
+// The stack traces of all the go routines are logged and the exit function is called with the immediate termination exit code.
+// Zero (the default) disables it. It has no effect if the grace period is infinite.
+func WithForceExitAfter(d time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.forceExitAfter = d
+	}
+}
+
+// armForceExit spawns a go routine that terminates the process once ctx is exceeded by `forceExitAfter`, and returns a function that disarms it.
+func (o *Daemon) armForceExit(ctx context.Context) func() {
+	if o.config.forceExitAfter <= 0 {
+		return func() {}
+	}
+
+	disarm := make(chan struct{})
+	go func() {
+		select {
+		case <-disarm:
+			return
+		case <-ctx.Done():
+		}
+
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+
+		t := time.NewTimer(o.config.forceExitAfter)
+		defer t.Stop()
+
+		select {
+		case <-disarm:
+		case <-t.C:
+			o.config.logger.ErrorContext(o.ctx, "shutdown callbacks still running after the grace period",
+				slog.Duration("force_exit_after", o.config.forceExitAfter),
+				slog.String("goroutines", string(goroutineDump())),
+			)
+			o.forceExit()
+		}
+	}()
+
+	return func() { close(disarm) }
+}
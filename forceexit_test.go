@@ -0,0 +1,62 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestForceExitAfter(t *testing.T) {
+	t.Run("hung callback", func(t *testing.T) {
+		release := make(chan struct{})
+
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+		s.EXPECT().OSExit(2).Run(func(int) { close(release) }).Once()
+
+		d := Start(
+			context.Background(),
+			WithLogger(logger(t)),
+			withSTDAPI(s),
+			WithShutdownGraceDuration(10*time.Millisecond),
+			WithForceExitAfter(10*time.Millisecond),
+		)
+
+		// ignores the cancellation of its context.
+		d.Defer(func(context.Context) { <-release })
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.True(t, d.forced)
+	})
+
+	t.Run("callbacks done in time", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(
+			context.Background(),
+			WithLogger(logger(t)),
+			withSTDAPI(s),
+			WithShutdownGraceDuration(10*time.Millisecond),
+			WithForceExitAfter(time.Second),
+		)
+
+		// exceeds the grace period, but returns before the force exit.
+		d.Defer(func(ctx context.Context) {
+			<-ctx.Done()
+			time.Sleep(10 * time.Millisecond)
+		})
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.False(t, d.forced)
+	})
+}
@@ -0,0 +1,150 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// GraceUsage describes how much of the shutdown grace period has been consumed, when a threshold configured using `WithGraceWarnings` is reached.
+type GraceUsage struct {
+	// Threshold is the fraction of the grace period that has been consumed (e.g. 0.5).
+	Threshold float64
+	// Elapsed time since the grace period started.
+	Elapsed time.Duration
+	// Grace is the whole grace period.
+	Grace time.Duration
+	// Done are the names of the shutdown callbacks that have already run.
+	Done []string
+	// Running is the name of the shutdown callback that is running, empty while the drain stages run.
+	Running string
+	// Remaining are the names of the shutdown callbacks that have not started yet.
+	Remaining []string
+}
+
+// WithGraceWarnings sets the fractions of the shutdown grace period (e.g. 0.5 for 50%) at which a warning is logged, with the shutdown callbacks
+// that have run and the ones that remain, and the functions registered using `OnGraceUsage` are called.
+// The default thresholds are 50% and 80%; calling it without thresholds disables the warnings. It has no effect if the grace period is infinite.
+func WithGraceWarnings(thresholds ...float64) DaemonConfigOption {
+	return func(oc *config) {
+		oc.graceWarnings = thresholds
+	}
+}
+
+// OnGraceUsage appends functions to be called when a grace period threshold (see `WithGraceWarnings`) is reached, e.g. to emit an event or a metric.
+func (o *Daemon) OnGraceUsage(f ...func(context.Context, GraceUsage)) {
+	o.onGraceUsageMutex.Lock()
+	defer o.onGraceUsageMutex.Unlock()
+	o.onGraceUsage = append(o.onGraceUsage, f...)
+}
+
+// OnShutdownTimeout appends functions to be called as soon as the shutdown grace period is exceeded, before the remaining callbacks are skipped,
+// e.g. to emit a metric or an alert. The usage describes the callback that is running (empty while the drain stages run) and the ones that will not run.
+// The grace period's threshold reported is 1, and the elapsed time includes any extension (see `ExtendGrace`).
+func (o *Daemon) OnShutdownTimeout(f ...func(context.Context, GraceUsage)) {
+	o.onGraceUsageMutex.Lock()
+	defer o.onGraceUsageMutex.Unlock()
+	o.onShutdownTimeout = append(o.onShutdownTimeout, f...)
+}
+
+// armShutdownTimeout calls the functions registered using `OnShutdownTimeout` once ctx is exceeded, and returns a function that disarms them.
+// The returned function waits for the functions that are in progress.
+func (o *Daemon) armShutdownTimeout(ctx context.Context, grace time.Duration) func() {
+	o.onGraceUsageMutex.Lock()
+	fns := o.onShutdownTimeout
+	o.onGraceUsageMutex.Unlock()
+
+	if len(fns) == 0 {
+		return func() {}
+	}
+
+	o.mu.Lock()
+	names := o.shutdownCallbacks
+	o.mu.Unlock()
+
+	start := time.Now()
+	done := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		defer close(done)
+		if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return
+		}
+
+		usage := graceUsage(1, time.Since(start), grace, names, int(o.callbackPosition.Load()))
+		for _, f := range fns {
+			f(ctx, usage)
+		}
+	})
+
+	return func() {
+		if !stop() {
+			<-done
+		}
+	}
+}
+
+// startGraceWarnings arms a timer per grace warning threshold and returns a function that stops them.
+func (o *Daemon) startGraceWarnings(ctx context.Context, grace time.Duration) func() {
+	if len(o.config.graceWarnings) == 0 {
+		return func() {}
+	}
+
+	o.mu.Lock()
+	names := o.shutdownCallbacks
+	o.mu.Unlock()
+
+	start := time.Now()
+
+	wg := sync.WaitGroup{}
+	timers := make([]*time.Timer, 0, len(o.config.graceWarnings))
+	for _, threshold := range o.config.graceWarnings {
+		wg.Add(1)
+		timers = append(timers, time.AfterFunc(time.Duration(float64(grace)*threshold), func() {
+			defer wg.Done()
+			o.graceThresholdReached(ctx, graceUsage(threshold, time.Since(start), grace, names, int(o.callbackPosition.Load())))
+		}))
+	}
+
+	return func() {
+		for _, t := range timers {
+			if t.Stop() {
+				wg.Done()
+			}
+		}
+		// wait for any warning that is in progress.
+		wg.Wait()
+	}
+}
+
+func (o *Daemon) graceThresholdReached(ctx context.Context, usage GraceUsage) {
+	o.config.logger.WarnContext(o.ctx, "shutdown grace period usage",
+		slog.Float64("threshold", usage.Threshold),
+		slog.Duration("elapsed", usage.Elapsed),
+		slog.Duration("grace", usage.Grace),
+		slog.Any("done", usage.Done),
+		slog.String("running", usage.Running),
+		slog.Any("remaining", usage.Remaining),
+	)
+
+	o.onGraceUsageMutex.Lock()
+	fns := o.onGraceUsage
+	o.onGraceUsageMutex.Unlock()
+
+	for _, f := range fns {
+		f(ctx, usage)
+	}
+}
+
+// graceUsage builds the usage given the position of the running callback (-1 while the drain stages run).
+func graceUsage(threshold float64, elapsed, grace time.Duration, names []string, position int) GraceUsage {
+	u := GraceUsage{Threshold: threshold, Elapsed: elapsed, Grace: grace, Remaining: names}
+	if position >= 0 && position < len(names) {
+		u.Done = names[:position]
+		u.Running = names[position]
+		u.Remaining = names[position+1:]
+	}
+
+	return u
+}
@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGraceWarnings(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithShutdownGraceDuration(200*time.Millisecond),
+		WithGraceWarnings(0.25, 0.5),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	mu := sync.Mutex{}
+	usages := []GraceUsage{}
+	d.OnGraceUsage(func(_ context.Context, u GraceUsage) {
+		mu.Lock()
+		defer mu.Unlock()
+		usages = append(usages, u)
+	})
+
+	// runs: Stop, slow, Stop.
+	d.Defer(namedModule{}.Stop)
+	d.Defer(func(context.Context) { time.Sleep(150 * time.Millisecond) })
+	d.Defer(namedModule{}.Stop)
+
+	d.ShutDown()
+	d.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	if assert.Len(t, usages, 2) {
+		for i, threshold := range []float64{0.25, 0.5} {
+			assert.InDelta(t, threshold, usages[i].Threshold, 0.001)
+			assert.Equal(t, 200*time.Millisecond, usages[i].Grace)
+			assert.Equal(t, []string{"daemon.namedModule.Stop"}, usages[i].Done)
+			assert.Equal(t, "daemon.TestGraceWarnings.func2", usages[i].Running)
+			assert.Equal(t, []string{"daemon.namedModule.Stop"}, usages[i].Remaining)
+		}
+		assert.GreaterOrEqual(t, usages[1].Elapsed, 100*time.Millisecond)
+	}
+}
+
+func TestGraceUsage(t *testing.T) {
+	names := []string{"a", "b", "c"}
+
+	u := graceUsage(0.5, time.Second, 2*time.Second, names, -1)
+	assert.Empty(t, u.Done)
+	assert.Empty(t, u.Running)
+	assert.Equal(t, names, u.Remaining)
+
+	u = graceUsage(0.5, time.Second, 2*time.Second, names, 2)
+	assert.Equal(t, []string{"a", "b"}, u.Done)
+	assert.Equal(t, "c", u.Running)
+	assert.Empty(t, u.Remaining)
+}
+
+func TestOnShutdownTimeout(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithShutdownGraceDuration(20*time.Millisecond), WithLogger(logger(t)), withSTDAPI(s))
+
+	calls := 0
+	var got GraceUsage
+	d.OnShutdownTimeout(func(_ context.Context, u GraceUsage) {
+		calls++
+		got = u
+	})
+
+	d.Defer(namedModule{}.Stop)
+	d.Defer(func(ctx context.Context) { <-ctx.Done() })
+	d.Defer(namedModule{}.Stop)
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.Equal(t, 1, calls)
+	assert.InDelta(t, 1, got.Threshold, 0)
+	assert.Equal(t, []string{"daemon.namedModule.Stop"}, got.Done)
+	assert.Equal(t, "daemon.TestOnShutdownTimeout.func2", got.Running)
+	assert.Equal(t, []string{"daemon.namedModule.Stop"}, got.Remaining)
+	assert.GreaterOrEqual(t, got.Elapsed, 20*time.Millisecond)
+}
+
+func TestOnShutdownTimeoutNotExceeded(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithShutdownGraceDuration(time.Minute), WithLogger(logger(t)), withSTDAPI(s))
+
+	d.OnShutdownTimeout(func(context.Context, GraceUsage) { t.Error("the grace period is not exceeded") })
+	d.Defer(namedModule{}.Stop)
+
+	d.ShutDown()
+	d.Wait()
+}
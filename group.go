@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// Group owns several daemons of the same process (e.g. one per tenant or per protocol):
+// once any of them starts shutting down, the shutdown is propagated to the rest of them, with `ReasonGroup`.
+type Group struct {
+	mu      sync.Mutex
+	daemons []*Daemon
+	// shutdown is set once the group started shutting down, and cause is the cause given to the rest of the daemons.
+	shutdown bool
+	cause    error
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// GroupReport merges the reports of the daemons of a `Group`.
+type GroupReport struct {
+	// States of the daemons, in the order they were added to the group.
+	States []State `json:"states"`
+	// ExitCode is the highest exit code of the daemons (see `WaitExitCode`).
+	ExitCode int `json:"exit_code"`
+}
+
+// NewGroup returns a group that owns the given daemons.
+func NewGroup(daemons ...*Daemon) *Group {
+	g := &Group{done: make(chan struct{})}
+	g.Add(daemons...)
+
+	return g
+}
+
+// Add adds daemons to the group. A daemon that is added after the group has started shutting down gets shut down too.
+func (g *Group) Add(daemons ...*Daemon) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	for _, d := range daemons {
+		g.daemons = append(g.daemons, d)
+		if g.shutdown {
+			d.shutDownWith(ReasonGroup, g.cause)
+			continue
+		}
+
+		go func() {
+			select {
+			case <-d.stopping:
+				g.propagate(d)
+
+			// stop watching
+			case <-g.done:
+			}
+		}()
+	}
+}
+
+// Close stops watching the daemons of the group, without shutting them down: the shutdown of a daemon is no longer propagated.
+// It should be called once the group is not needed anymore, if its daemons may never shut down.
+func (g *Group) Close() {
+	g.closeOnce.Do(func() { close(g.done) })
+}
+
+// ShutDown initiates the graceful shutdown of every daemon of the group.
+func (g *Group) ShutDown() {
+	for _, d := range g.members() {
+		d.ShutDown()
+	}
+}
+
+// WaitAll blocks until the graceful shutdown of every daemon of the group is done, or until the given context (the overall deadline) is done,
+// in which case it returns the context's error. The report is returned in both cases.
+func (g *Group) WaitAll(ctx context.Context) (GroupReport, error) {
+	var err error
+	for _, d := range g.members() {
+		if err = d.WaitContext(ctx); err != nil {
+			break
+		}
+	}
+
+	report := GroupReport{}
+	for _, d := range g.members() {
+		report.States = append(report.States, d.State())
+		report.ExitCode = max(report.ExitCode, d.exitCode())
+	}
+
+	return report, err
+}
+
+// propagate shuts down every daemon of the group, because the given one started shutting down.
+// The cause of the given daemon's shutdown, if any, is wrapped in the cause given to the rest of them, so a clean shutdown stays clean.
+func (g *Group) propagate(from *Daemon) {
+	// the group may be closed while from started shutting down.
+	select {
+	case <-g.done:
+		return
+	default:
+	}
+
+	var cause error
+	if err := from.ShutdownCause(); err != nil {
+		cause = fmt.Errorf("daemon %s of the group is shutting down: %w", from.InstanceID(), err)
+	}
+
+	g.mu.Lock()
+	if !g.shutdown {
+		g.shutdown, g.cause = true, cause
+	}
+	g.mu.Unlock()
+
+	for _, d := range g.members() {
+		if d != from {
+			d.shutDownWith(ReasonGroup, cause)
+		}
+	}
+}
+
+func (g *Group) members() []*Daemon {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.daemons
+}
@@ -0,0 +1,97 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestGroup(t *testing.T) {
+	daemons := make([]*Daemon, 0, 3)
+	for range 3 {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+		daemons = append(daemons, Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s)))
+	}
+
+	g := NewGroup(daemons[0], daemons[1])
+	g.Add(daemons[2])
+
+	errGone := errors.New("tenant database is gone")
+	daemons[1].FatalErrorsChannel() <- errGone
+
+	ctx, cancel := context.WithTimeout(t.Context(), time.Second)
+	defer cancel()
+	report, err := g.WaitAll(ctx)
+	assert.NoError(t, err)
+
+	if assert.Len(t, report.States, 3) {
+		assert.Equal(t, ReasonGroup, report.States[0].Reason)
+		assert.Equal(t, ReasonFatalError, report.States[1].Reason)
+		assert.Equal(t, ReasonGroup, report.States[2].Reason)
+		for _, st := range report.States {
+			assert.Equal(t, StatusStopped, st.Status)
+		}
+	}
+	assert.Equal(t, 1, report.ExitCode)
+	assert.ErrorContains(t, daemons[0].ShutdownCause(), "daemon "+daemons[1].InstanceID()+" of the group is shutting down")
+	assert.ErrorIs(t, daemons[0].ShutdownCause(), errGone)
+
+	// a daemon added once the group is shutting down is shut down too.
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+	late := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+	g.Add(late)
+	late.Wait()
+	assert.Equal(t, ReasonGroup, late.ShutdownReason())
+	assert.ErrorIs(t, late.ShutdownCause(), errGone)
+}
+
+func TestGroupCleanShutdown(t *testing.T) {
+	daemons := make([]*Daemon, 0, 2)
+	for range 2 {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+		daemons = append(daemons, Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s)))
+	}
+
+	g := NewGroup(daemons...)
+	daemons[0].ShutDown()
+
+	_, err := g.WaitAll(t.Context())
+	assert.NoError(t, err)
+
+	// a clean shutdown of one daemon is clean for the rest of them.
+	assert.Equal(t, ReasonGroup, daemons[1].ShutdownReason())
+	assert.NoError(t, daemons[1].WaitErr())
+}
+
+func TestGroupClose(t *testing.T) {
+	daemons := make([]*Daemon, 0, 2)
+	for range 2 {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+		daemons = append(daemons, Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s)))
+	}
+
+	g := NewGroup(daemons...)
+	g.Close()
+	g.Close()
+
+	// once closed, the shutdown is no longer propagated.
+	daemons[0].ShutDown()
+	daemons[0].Wait()
+	assert.Equal(t, StatusRunning, daemons[1].State().Status)
+
+	daemons[1].ShutDown()
+	daemons[1].Wait()
+	assert.Equal(t, ReasonManual, daemons[1].ShutdownReason())
+}
@@ -0,0 +1,98 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+)
+
+// UnaryServerInterceptor returns a grpc unary server interceptor that counts the in-flight RPCs, and registers (once, using `Defer`) a shutdown callback
+// that waits for them to complete, bounded by the grace period. The interceptor is generic over the grpc types, so the package does not depend on grpc:
+//
+//	grpc.UnaryInterceptor(daemon.UnaryServerInterceptor[grpc.UnaryServerInfo, grpc.UnaryHandler](d))
+func UnaryServerInterceptor[I any, H ~func(context.Context, any) (any, error)](d *Daemon) func(context.Context, any, *I, H) (any, error) {
+	d.deferWait(&d.rpcInFlight, "daemon.rpc_in_flight", "rpcs")
+
+	return func(ctx context.Context, req any, _ *I, handler H) (any, error) {
+		d.rpcInFlight.add()
+		defer d.rpcInFlight.done()
+
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor is like `UnaryServerInterceptor`, for the streaming RPCs:
+//
+//	grpc.StreamInterceptor(daemon.StreamServerInterceptor[grpc.ServerStream, grpc.StreamServerInfo, grpc.StreamHandler](d))
+func StreamServerInterceptor[S any, I any, H ~func(any, S) error](d *Daemon) func(any, S, *I, H) error {
+	d.deferWait(&d.rpcInFlight, "daemon.rpc_in_flight", "rpcs")
+
+	return func(srv any, ss S, _ *I, handler H) error {
+		d.rpcInFlight.add()
+		defer d.rpcInFlight.done()
+
+		return handler(srv, ss)
+	}
+}
+
+// GRPCServer is the part of `*grpc.Server` used by the grpc adapters.
+type GRPCServer interface {
+	Serve(net.Listener) error
+	GracefulStop()
+	Stop()
+}
+
+// GRPCServerModule returns a `Module` that serves srv on ln, in a goroutine started using `Run` (so a serving error shuts the daemon down),
+// and stops it using `GracefulStop`, which stops accepting new RPCs and waits for the in-flight ones, falling back to `Stop` once the shutdown context is done.
+func GRPCServerModule(d *Daemon, name string, srv GRPCServer, ln net.Listener) Module {
+	return Module{
+		Name: name,
+		Start: func(context.Context) error {
+			d.Run(name, func(context.Context) error { return srv.Serve(ln) })
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			d.stopGRPCServer(ctx, name, srv)
+			return nil
+		},
+	}
+}
+
+// ServeGRPC serves srv on the listener created using `Listen` (so it is adopted from systemd or from the old process on upgrade), in a goroutine started using `Run`,
+// and registers, using `Defer`, a shutdown callback that stops srv using `GracefulStop`, falling back to `Stop` once the shutdown context is done.
+// It is the equivalent of `GRPCServerModule` without the module graph.
+func ServeGRPC(d *Daemon, name string, srv GRPCServer, network, address string) error {
+	ln, err := d.Listen(name, network, address)
+	if err != nil {
+		return err
+	}
+
+	d.Run(name, func(context.Context) error {
+		// the listener is closed by the daemon at the beginning of the drain.
+		if err := srv.Serve(ln); !errors.Is(err, net.ErrClosed) {
+			return err
+		}
+		return nil
+	})
+	d.deferNamed(name, func(ctx context.Context) { d.stopGRPCServer(ctx, name, srv) })
+
+	return nil
+}
+
+// stopGRPCServer calls `GracefulStop`, and `Stop` if ctx is done before the graceful stop.
+func (o *Daemon) stopGRPCServer(ctx context.Context, name string, srv GRPCServer) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		o.config.logger.WarnContext(ctx, "force stopping grpc server", slog.String("server", name), slog.Int("in_flight", o.rpcInFlight.count()))
+		srv.Stop()
+		<-stopped
+	}
+}
@@ -0,0 +1,184 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// fake grpc types, with the same shape as the grpc ones.
+type (
+	fakeUnaryServerInfo   struct{}
+	fakeUnaryHandler      func(ctx context.Context, req any) (any, error)
+	fakeUnaryInterceptor  func(ctx context.Context, req any, info *fakeUnaryServerInfo, handler fakeUnaryHandler) (any, error)
+	fakeServerStream      interface{ Context() context.Context }
+	fakeStreamServerInfo  struct{}
+	fakeStreamHandler     func(srv any, stream fakeServerStream) error
+	fakeStreamInterceptor func(srv any, ss fakeServerStream, info *fakeStreamServerInfo, handler fakeStreamHandler) error
+)
+
+func TestServerInterceptors(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), WithShutdownGraceDuration(time.Second))
+
+	var unary fakeUnaryInterceptor = UnaryServerInterceptor[fakeUnaryServerInfo, fakeUnaryHandler](d)
+	var stream fakeStreamInterceptor = StreamServerInterceptor[fakeServerStream, fakeStreamServerInfo, fakeStreamHandler](d)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan struct{}, 2)
+
+	go func() {
+		resp, err := unary(t.Context(), "req", &fakeUnaryServerInfo{}, func(_ context.Context, req any) (any, error) {
+			started <- struct{}{}
+			<-release
+			return req, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "req", resp)
+		done <- struct{}{}
+	}()
+	go func() {
+		err := stream(nil, nil, &fakeStreamServerInfo{}, func(any, fakeServerStream) error {
+			started <- struct{}{}
+			<-release
+			return nil
+		})
+		assert.NoError(t, err)
+		done <- struct{}{}
+	}()
+	<-started
+	<-started
+	assert.Equal(t, 2, d.rpcInFlight.count())
+
+	d.ShutDown()
+	close(release)
+	d.Wait()
+
+	// the shutdown waited for the in-flight rpcs.
+	assert.Equal(t, 0, d.rpcInFlight.count())
+	<-done
+	<-done
+}
+
+type fakeGRPCServer struct {
+	gracefulDone chan struct{}
+
+	mu             sync.Mutex
+	ln             net.Listener
+	gracefulCalled bool
+	stopCalled     bool
+	stopOnce       sync.Once
+	stopped        chan struct{}
+}
+
+func (f *fakeGRPCServer) Serve(ln net.Listener) error {
+	f.mu.Lock()
+	f.ln = ln
+	f.mu.Unlock()
+
+	<-f.stopped
+
+	return nil
+}
+
+func (f *fakeGRPCServer) GracefulStop() {
+	f.mu.Lock()
+	f.gracefulCalled = true
+	f.mu.Unlock()
+
+	select {
+	case <-f.gracefulDone:
+	case <-f.stopped:
+	}
+	f.stopOnce.Do(func() { close(f.stopped) })
+}
+
+func (f *fakeGRPCServer) Stop() {
+	f.mu.Lock()
+	f.stopCalled = true
+	f.mu.Unlock()
+
+	f.stopOnce.Do(func() { close(f.stopped) })
+}
+
+func TestGRPCServerModule(t *testing.T) {
+	tests := map[string]struct {
+		gracefulDone bool
+		expectedStop bool
+	}{
+		"graceful stop": {
+			gracefulDone: true,
+			expectedStop: false,
+		},
+		"force stop": {
+			gracefulDone: false,
+			expectedStop: true,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), WithShutdownGraceDuration(20*time.Millisecond))
+
+			srv := &fakeGRPCServer{gracefulDone: make(chan struct{}), stopped: make(chan struct{})}
+			if tc.gracefulDone {
+				close(srv.gracefulDone)
+			}
+
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err)
+			defer ln.Close()
+
+			d.Register(GRPCServerModule(d, "grpc", srv, ln))
+			require.NoError(t, d.StartModules())
+
+			d.ShutDown()
+			d.Wait()
+
+			assert.Equal(t, ReasonManual, d.ShutdownReason())
+			srv.mu.Lock()
+			defer srv.mu.Unlock()
+			assert.Equal(t, ln, srv.ln)
+			assert.True(t, srv.gracefulCalled)
+			assert.Equal(t, tc.expectedStop, srv.stopCalled)
+		})
+	}
+}
+
+func TestServeGRPC(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), WithShutdownGraceDuration(20*time.Millisecond))
+
+	srv := &fakeGRPCServer{gracefulDone: make(chan struct{}), stopped: make(chan struct{})}
+	close(srv.gracefulDone)
+
+	require.NoError(t, ServeGRPC(d, "grpc", srv, "tcp", "127.0.0.1:0"))
+	require.Error(t, ServeGRPC(d, "grpc-invalid", srv, "tcp", "invalid address"))
+
+	d.ShutDown()
+	d.Wait()
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	require.NotNil(t, srv.ln)
+	assert.Equal(t, d.listeners[0].ln, srv.ln)
+	assert.True(t, srv.gracefulCalled)
+	assert.False(t, srv.stopCalled)
+}
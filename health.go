@@ -0,0 +1,154 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrUnhealthy is pushed (wrapped) to the fatal errors channel when a health check registered using `WithFatalAfterFailures` fails too many times in a row.
+var ErrUnhealthy = errors.New("unhealthy")
+
+// HealthCheckOption configures a health check registered using `RegisterHealthCheck`.
+type HealthCheckOption func(*healthCheck)
+
+// WithFatalAfterFailures makes the health check push an `ErrUnhealthy` fatal error once it fails n times in a row. Zero (the default) never does.
+// With `WithHealthCheckInterval` only the periodic runs count, so the probes do not speed it up; otherwise every run of `Health` counts.
+func WithFatalAfterFailures(n int) HealthCheckOption {
+	return func(hc *healthCheck) {
+		hc.fatalAfter = n
+	}
+}
+
+// WithHealthCheckInterval makes the daemon run the health checks every interval, until the shutdown process starts,
+// so the consecutive failures of `WithFatalAfterFailures` are counted once per interval, no matter how often the health is probed.
+// Zero (the default) runs them only on demand.
+func WithHealthCheckInterval(interval time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.healthCheckInterval = interval
+	}
+}
+
+type healthCheck struct {
+	name       string
+	fn         func(context.Context) error
+	fatalAfter int
+	// failures is the number of consecutive failures, guarded by `healthMutex`.
+	failures int
+}
+
+// HealthReport is the JSON report served by the handler returned from `HealthHandler`.
+type HealthReport struct {
+	// Status is "ok" if every check passed, "unhealthy" otherwise.
+	Status string `json:"status"`
+	// Checks are the results of the checks by name: "ok" or the error.
+	Checks map[string]string `json:"checks"`
+}
+
+// RegisterHealthCheck registers a named health check, replacing the one with the same name if any.
+func (o *Daemon) RegisterHealthCheck(name string, fn func(context.Context) error, opts ...HealthCheckOption) {
+	hc := &healthCheck{name: name, fn: fn}
+	for _, opt := range opts {
+		opt(hc)
+	}
+
+	o.healthMutex.Lock()
+	defer o.healthMutex.Unlock()
+
+	for i, c := range o.healthChecks {
+		if c.name == name {
+			o.healthChecks[i] = hc
+			return
+		}
+	}
+	o.healthChecks = append(o.healthChecks, hc)
+}
+
+// Health runs every registered health check concurrently and returns their results by name (nil for the passing ones).
+func (o *Daemon) Health(ctx context.Context) map[string]error {
+	// the periodic runner, if any, is the one that counts the failures.
+	return o.runHealthChecks(ctx, o.config.healthCheckInterval <= 0)
+}
+
+// runHealthChecks runs every registered health check concurrently, and records their results if record is set.
+func (o *Daemon) runHealthChecks(ctx context.Context, record bool) map[string]error {
+	o.healthMutex.Lock()
+	checks := o.healthChecks
+	o.healthMutex.Unlock()
+
+	errs := make([]error, len(checks))
+	wg := sync.WaitGroup{}
+	for i, c := range checks {
+		wg.Go(func() {
+			errs[i] = c.fn(ctx)
+		})
+	}
+	wg.Wait()
+
+	results := make(map[string]error, len(checks))
+	for i, c := range checks {
+		results[c.name] = errs[i]
+		if record {
+			o.recordHealth(ctx, c, errs[i])
+		}
+	}
+
+	return results
+}
+
+// HealthHandler returns an http.Handler that runs the health checks and serves a JSON `HealthReport`, with 200 if every check passed and 503 otherwise.
+func (o *Daemon) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		report := HealthReport{Status: "ok", Checks: map[string]string{}}
+		for name, err := range o.Health(r.Context()) {
+			if err != nil {
+				report.Status = "unhealthy"
+				report.Checks[name] = err.Error()
+				continue
+			}
+			report.Checks[name] = "ok"
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if report.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
+
+// recordHealth counts the consecutive failures of the check and pushes a fatal error once its threshold is reached.
+func (o *Daemon) recordHealth(ctx context.Context, c *healthCheck, err error) {
+	o.healthMutex.Lock()
+	if err == nil {
+		c.failures = 0
+		o.healthMutex.Unlock()
+		return
+	}
+	c.failures++
+	failures := c.failures
+	o.healthMutex.Unlock()
+
+	o.config.logger.WarnContext(ctx, "health check failed", slog.String("check", c.name), slog.Int("failures", failures), slog.String("error", err.Error()))
+
+	if c.fatalAfter > 0 && failures == c.fatalAfter {
+		o.pushFatalError(fmt.Errorf("%w: %s: %w", ErrUnhealthy, c.name, err))
+	}
+}
+
+// startHealthChecks spawns a go routine that runs the health checks periodically, if configured.
+func (o *Daemon) startHealthChecks() {
+	if o.config.healthCheckInterval <= 0 {
+		return
+	}
+
+	go o.poll(o.config.healthCheckInterval, func() bool {
+		o.runHealthChecks(o.ctx, true)
+		return false
+	})
+}
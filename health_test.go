@@ -0,0 +1,95 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	errDown := errors.New("connection refused")
+	d.RegisterHealthCheck("db", func(context.Context) error { return errDown })
+	d.RegisterHealthCheck("cache", func(context.Context) error { return nil })
+	// replaces the failing check.
+	d.RegisterHealthCheck("db", func(context.Context) error { return nil })
+
+	assert.Equal(t, map[string]error{"db": nil, "cache": nil}, d.Health(t.Context()))
+
+	d.RegisterHealthCheck("queue", func(context.Context) error { return errDown })
+	assert.Equal(t, map[string]error{"db": nil, "cache": nil, "queue": errDown}, d.Health(t.Context()))
+
+	rec := httptest.NewRecorder()
+	d.HealthHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/health", nil))
+	assert.Equal(t, 503, rec.Code)
+
+	report := HealthReport{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, HealthReport{
+		Status: "unhealthy",
+		Checks: map[string]string{"db": "ok", "cache": "ok", "queue": "connection refused"},
+	}, report)
+
+	d.ShutDown()
+	d.Wait()
+	assert.Equal(t, ReasonManual, d.ShutdownReason())
+}
+
+func TestHealthFatalAfterFailures(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithHealthCheckInterval(time.Millisecond), WithLogger(logger(t)), withSTDAPI(s))
+
+	errDown := errors.New("connection refused")
+	failures := atomic.Int32{}
+	d.RegisterHealthCheck("db", func(context.Context) error {
+		if failures.Add(1) == 2 {
+			// a success resets the consecutive failures.
+			return nil
+		}
+		return errDown
+	}, WithFatalAfterFailures(3))
+
+	d.Wait()
+
+	assert.Equal(t, ReasonFatalError, d.ShutdownReason())
+	assert.ErrorIs(t, d.ShutdownCause(), ErrUnhealthy)
+	assert.ErrorIs(t, d.ShutdownCause(), errDown)
+	assert.GreaterOrEqual(t, failures.Load(), int32(5))
+}
+
+func TestHealthProbesDoNotCountFailures(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithHealthCheckInterval(time.Hour), WithLogger(logger(t)), withSTDAPI(s))
+
+	errDown := errors.New("connection refused")
+	d.RegisterHealthCheck("db", func(context.Context) error { return errDown }, WithFatalAfterFailures(2))
+
+	// only the periodic runs count toward the consecutive failures.
+	for range 5 {
+		assert.Equal(t, map[string]error{"db": errDown}, d.Health(t.Context()))
+	}
+	assert.Equal(t, StatusRunning, d.State().Status)
+
+	d.ShutDown()
+	d.Wait()
+	assert.Equal(t, ReasonManual, d.ShutdownReason())
+}
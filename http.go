@@ -0,0 +1,149 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// HTTPDrainOption configures the staged drain of an http server managed using `DrainHTTPServer`.
+type HTTPDrainOption func(*httpDrainConfig)
+
+type httpDrainConfig struct {
+	idleCloseAt  float64
+	forceCloseAt float64
+}
+
+// WithIdleCloseAt sets the point of the callback's grace window, as a fraction in [0, 1], where the listeners and the idle connections get closed.
+// The default is 0, immediately.
+func WithIdleCloseAt(fraction float64) HTTPDrainOption {
+	return func(hc *httpDrainConfig) {
+		hc.idleCloseAt = fraction
+	}
+}
+
+// WithForceCloseAt sets the point of the callback's grace window, as a fraction in [0, 1], where the active connections get force closed.
+// The default is 0.9, so the last 10% of the window is left to the shutdown callbacks that run after.
+func WithForceCloseAt(fraction float64) HTTPDrainOption {
+	return func(hc *httpDrainConfig) {
+		hc.forceCloseAt = fraction
+	}
+}
+
+// DrainHTTPServer registers, using `Defer`, a staged drain of the given http server:
+//
+//  1. Keep-alives are disabled immediately, so clients stop reusing their connections.
+//  2. At the point configured using `WithIdleCloseAt`, `srv.Shutdown` is called, closing the listeners and the idle connections.
+//  3. At the point configured using `WithForceCloseAt`, if active connections remain, they get force closed using `srv.Close`.
+//
+// The points are fractions of the grace window of the callback (its context's deadline). If the grace period is infinite,
+// `srv.Shutdown` is called immediately and the active connections are never force closed.
+func DrainHTTPServer(d *Daemon, srv *http.Server, opts ...HTTPDrainOption) {
+	cnf := httpDrainConfig{forceCloseAt: defaultHTTPForceCloseAt}
+	for _, o := range opts {
+		o(&cnf)
+	}
+
+	d.Defer(func(ctx context.Context) {
+		srv.SetKeepAlivesEnabled(false)
+
+		deadline, ok := ctx.Deadline()
+		if !ok {
+			_ = d.stopHTTPServer(ctx, srv.Addr, srv)
+			return
+		}
+
+		start := time.Now()
+		window := deadline.Sub(start)
+		at := func(fraction float64) time.Time { return start.Add(time.Duration(float64(window) * fraction)) }
+
+		t := time.NewTimer(time.Until(at(cnf.idleCloseAt)))
+		defer t.Stop()
+
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+		}
+
+		shutdownCTX, cancel := context.WithDeadline(ctx, at(cnf.forceCloseAt))
+		defer cancel()
+
+		_ = d.stopHTTPServer(shutdownCTX, srv.Addr, srv)
+	})
+}
+
+// stopHTTPServer calls `Shutdown`, and `Close` if ctx is done before the active connections are closed.
+func (o *Daemon) stopHTTPServer(ctx context.Context, name string, srv *http.Server) error {
+	err := srv.Shutdown(ctx)
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		o.config.logger.WarnContext(ctx, "force closing the active connections of http server", slog.String("server", name))
+		return srv.Close()
+	}
+
+	return err
+}
+
+// TrackHTTP returns a middleware that counts the in-flight requests of next, and registers (once, using `Defer`) a shutdown callback
+// that waits for them to complete, bounded by the grace period. A request is in-flight until next returns, so handlers of hijacked connections
+// (e.g. websockets) are tracked as long as they serve the connection. Once the shutdown has started, the responses ask the clients to close
+// their connections (`Connection: close`), so they reconnect to another instance.
+func (o *Daemon) TrackHTTP(next http.Handler) http.Handler {
+	o.deferWait(&o.httpInFlight, "daemon.http_in_flight", "http requests")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		o.httpInFlight.add()
+		defer o.httpInFlight.done()
+
+		select {
+		case <-o.stopping:
+			w.Header().Set("Connection", "close")
+		default:
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// HTTPServer returns a `Module` for srv: on start, srv's `BaseContext` (if not set) is wired to the daemon's context, srv.Addr is listened on
+// (":http" if empty) using `Listen` with the module's name, so a listen error fails the start, and srv serves in a goroutine started using `Run`,
+// so an error other than `http.ErrServerClosed` or `net.ErrClosed` shuts the daemon down. Being a managed listener, it is closed at the beginning
+// of the drain, handed over on `Upgrade` and stored in the systemd file descriptor store if enabled.
+// On stop, srv is shut down using `Shutdown`, and closed if the shutdown context is done before.
+// It serves plain http; a TLS server should be managed directly (e.g. using `Run` and `DrainHTTPServer`).
+func HTTPServer(d *Daemon, name string, srv *http.Server) Module {
+	return Module{
+		Name: name,
+		Start: func(context.Context) error {
+			if srv.BaseContext == nil {
+				srv.BaseContext = func(net.Listener) context.Context { return d.CTX() }
+			}
+
+			addr := srv.Addr
+			if addr == "" {
+				addr = ":http"
+			}
+			ln, err := d.Listen(name, "tcp", addr)
+			if err != nil {
+				return err
+			}
+
+			d.Run(name, func(context.Context) error {
+				// the listener is closed at the beginning of the drain, before srv is shut down.
+				if err := srv.Serve(ln); !errors.Is(err, http.ErrServerClosed) && !errors.Is(err, net.ErrClosed) {
+					return err
+				}
+				return nil
+			})
+
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			srv.SetKeepAlivesEnabled(false)
+
+			return d.stopHTTPServer(ctx, name, srv)
+		},
+	}
+}
@@ -0,0 +1,213 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrainHTTPServer(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithShutdownGraceDuration(400*time.Millisecond),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	started := make(chan struct{})
+	srv := &http.Server{
+		ReadHeaderTimeout: time.Second,
+		Handler: http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-r.Context().Done()
+		}),
+	}
+	served := make(chan struct{})
+	go func() {
+		_ = srv.Serve(ln)
+		close(served)
+	}()
+
+	DrainHTTPServer(d, srv, WithIdleCloseAt(0.25), WithForceCloseAt(0.5))
+
+	requestErr := make(chan error)
+	go func() {
+		resp, err := http.Get("http://" + ln.Addr().String()) //nolint:noctx
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		requestErr <- err
+	}()
+	<-started
+
+	start := time.Now()
+	d.ShutDown()
+
+	// the listener is still open before the idle close point.
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if assert.NoError(t, err) {
+		_ = conn.Close()
+	}
+
+	// the active request is force closed at the force close point.
+	assert.Error(t, <-requestErr)
+	elapsed := time.Since(start)
+	assert.GreaterOrEqual(t, elapsed, 200*time.Millisecond)
+	assert.Less(t, elapsed, 400*time.Millisecond)
+
+	d.Wait()
+	<-served
+
+	_, err = net.Dial("tcp", ln.Addr().String())
+	assert.Error(t, err)
+}
+
+func TestTrackHTTP(t *testing.T) {
+	tests := map[string]struct {
+		grace            time.Duration
+		release          bool
+		expectedInFlight int
+	}{
+		"requests complete": {
+			grace:            time.Second,
+			release:          true,
+			expectedInFlight: 0,
+		},
+		"grace exceeded": {
+			grace:            20 * time.Millisecond,
+			release:          false,
+			expectedInFlight: 2,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), WithShutdownGraceDuration(tc.grace))
+
+			started := make(chan struct{})
+			release := make(chan struct{})
+			h := d.TrackHTTP(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				started <- struct{}{}
+				<-release
+			}))
+
+			serve := func() chan *httptest.ResponseRecorder {
+				served := make(chan *httptest.ResponseRecorder, 1)
+				go func() {
+					rec := httptest.NewRecorder()
+					h.ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+					served <- rec
+				}()
+				<-started
+
+				return served
+			}
+
+			first := serve()
+			d.ShutDown()
+
+			// the requests received during the shutdown are still served, asking the client to close the connection.
+			second := serve()
+
+			if tc.release {
+				close(release)
+			}
+			d.Wait()
+			assert.Equal(t, tc.expectedInFlight, d.httpInFlight.count())
+
+			if !tc.release {
+				close(release)
+			}
+			assert.Empty(t, (<-first).Header().Get("Connection"))
+			assert.Equal(t, "close", (<-second).Header().Get("Connection"))
+		})
+	}
+}
+
+type testCTXKey struct{}
+
+func TestHTTPServer(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	parentCTX := context.WithValue(context.Background(), testCTXKey{}, "parent")
+	d := Start(parentCTX, WithLogger(logger(t)), withSTDAPI(s), WithShutdownGraceDuration(time.Second))
+
+	// reserve a free port.
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := ln.Addr().String()
+	require.NoError(t, ln.Close())
+
+	d.Register(HTTPServer(d, "http", &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// the requests' context derives from the daemon's one.
+			assert.Equal(t, "parent", r.Context().Value(testCTXKey{}))
+			w.WriteHeader(http.StatusTeapot)
+		}),
+		ReadHeaderTimeout: time.Second,
+	}))
+	require.NoError(t, d.StartModules())
+
+	resp, err := http.Get("http://" + addr) //nolint:noctx // test.
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, http.StatusTeapot, resp.StatusCode)
+
+	var dialErr error
+	d.Defer(func(context.Context) {
+		// the managed listener is closed before the shutdown callbacks, including the module's stop.
+		conn, err := net.DialTimeout("tcp", addr, time.Second)
+		if err == nil {
+			_ = conn.Close()
+		}
+		dialErr = err
+	})
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.Error(t, dialErr)
+	assert.Equal(t, ReasonManual, d.ShutdownReason())
+	_, err = http.Get("http://" + addr) //nolint:noctx // test.
+	assert.Error(t, err)
+}
+
+func TestHTTPServerListenError(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer ln.Close()
+
+	// the address is already in use.
+	d.Register(HTTPServer(d, "http", &http.Server{Addr: ln.Addr().String(), ReadHeaderTimeout: time.Second}))
+	require.ErrorIs(t, d.StartModules(), ErrStartup)
+
+	d.Wait()
+	assert.Equal(t, ReasonFatalError, d.ShutdownReason())
+}
@@ -0,0 +1,33 @@
+package daemon
+
+import (
+	"log/slog"
+	"time"
+)
+
+// WithIdleShutdown initiates a graceful shutdown once no activity has been reported using `Touch()` for the given duration.
+// Zero duration (the default) disables the idle shutdown.
+func WithIdleShutdown(d time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.idleTimeout = d
+	}
+}
+
+// Touch reports activity to the daemon, postponing the idle shutdown configured using `WithIdleShutdown`.
+func (o *Daemon) Touch() {
+	o.lastActivity.Store(time.Now().UnixNano())
+}
+
+// startIdle spawns a go routine that will initiate the shutdown once the daemon stays idle for the configured duration.
+func (o *Daemon) startIdle() {
+	if o.config.idleTimeout <= 0 {
+		return
+	}
+
+	o.Touch()
+
+	go o.watchInactivity(&o.lastActivity, o.config.idleTimeout, func(idle time.Duration) {
+		o.config.logger.InfoContext(o.ctx, "idle timeout reached", slog.Duration("idle", idle))
+		o.shutDownWith(ReasonIdle, nil)
+	})
+}
@@ -0,0 +1,36 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestIdleShutdown(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(
+		context.Background(),
+		WithIdleShutdown(50*time.Millisecond),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	start := time.Now()
+	// keep the daemon busy for a while.
+	for range 5 {
+		time.Sleep(20 * time.Millisecond)
+		d.Touch()
+	}
+
+	d.Wait()
+
+	assert.GreaterOrEqual(t, time.Since(start), 150*time.Millisecond)
+	assert.Equal(t, ReasonIdle, d.State().Reason)
+}
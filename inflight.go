@@ -0,0 +1,81 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// inFlight counts the in-flight work (e.g. http requests) and lets the shutdown wait for it to complete.
+type inFlight struct {
+	once sync.Once
+
+	mu   sync.Mutex
+	n    int
+	idle chan struct{}
+}
+
+// add increments the in-flight count.
+func (f *inFlight) add() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.n == 0 {
+		f.idle = make(chan struct{})
+	}
+	f.n++
+}
+
+// done decrements the in-flight count.
+func (f *inFlight) done() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.n--
+	if f.n == 0 {
+		close(f.idle)
+	}
+}
+
+// count returns the in-flight count.
+func (f *inFlight) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.n
+}
+
+// wait blocks until the in-flight count is zero or ctx is done. It returns the in-flight count left.
+func (f *inFlight) wait(ctx context.Context) int {
+	for {
+		f.mu.Lock()
+		if f.n == 0 {
+			f.mu.Unlock()
+			return 0
+		}
+		idle := f.idle
+		f.mu.Unlock()
+
+		select {
+		case <-idle:
+			// new work may have started since.
+		case <-ctx.Done():
+			return f.count()
+		}
+	}
+}
+
+// deferWait registers once, using `deferNamed`, a shutdown callback that waits for the in-flight work of f to complete, bounded by the callback's grace.
+func (o *Daemon) deferWait(f *inFlight, name, kind string) {
+	f.once.Do(func() {
+		o.deferNamed(name, func(ctx context.Context) {
+			if n := f.count(); n > 0 {
+				o.config.logger.InfoContext(ctx, "waiting for the in-flight "+kind+" to complete", slog.Int("in_flight", n))
+			}
+
+			if n := f.wait(ctx); n > 0 {
+				o.config.logger.WarnContext(ctx, "in-flight "+kind+" did not complete in time", slog.Int("in_flight", n))
+			}
+		})
+	})
+}
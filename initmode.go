@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"log/slog"
+	"os"
+)
+
+// WithInitMode makes the daemon act as an init process when it runs as PID 1, e.g. as the entrypoint of a container:
+// besides the normal signal handling, the zombie children (the orphaned processes that get re-parented to PID 1) are reaped on SIGCHLD,
+// so there is no need for an init like tini. Like with `WithSubreaper`, the commands managed using `Command` are not reaped by it.
+// It has no effect if the process is not PID 1, and is only supported on linux.
+func WithInitMode() DaemonConfigOption {
+	return func(oc *config) {
+		oc.initMode = true
+	}
+}
+
+// startInitMode starts reaping the zombie children on SIGCHLD, if configured and running as PID 1.
+func (o *Daemon) startInitMode() {
+	if !o.config.initMode || os.Getpid() != 1 || sigCHLD == nil {
+		return
+	}
+
+	o.config.logger.InfoContext(o.ctx, "running as PID 1, reaping the zombie children")
+	o.watchChildren()
+}
+
+// watchChildren spawns a go routine that reaps the zombie children every time a SIGCHLD is received.
+// Unlike the other watchers, it keeps reaping during the shutdown, since the shutdown callbacks are likely to stop processes.
+func (o *Daemon) watchChildren() {
+	// a single pending SIGCHLD is enough, since every zombie child is reaped on each one.
+	chld := make(chan os.Signal, 1)
+	o.childSignalCh = chld
+	o.config.stdAPI.SignalNotify(chld, sigCHLD)
+
+	go func() {
+		for {
+			select {
+			case <-chld:
+				o.reap()
+
+			// stop the loop
+			case <-o.done:
+				return
+			}
+		}
+	}()
+}
+
+// stopWatchingChildren stops the SIGCHLD notifications, if any.
+func (o *Daemon) stopWatchingChildren() {
+	if o.childSignalCh != nil {
+		o.config.stdAPI.SignalStop(o.childSignalCh)
+	}
+}
+
+// reap reaps the zombie children of the process, except the commands managed using `Command`.
+func (o *Daemon) reap() {
+	reapMutex.Lock()
+	pids, err := reapOrphans(func(pid int) bool {
+		_, managed := managedPIDs.Load(pid)
+		return managed
+	})
+	reapMutex.Unlock()
+	if err != nil {
+		o.config.logger.DebugContext(o.ctx, "failed to reap orphans", slog.String("error", err.Error()))
+	}
+	for _, pid := range pids {
+		o.config.logger.DebugContext(o.ctx, "reaped orphan process", slog.Int("pid", pid))
+	}
+}
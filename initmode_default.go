@@ -0,0 +1,8 @@
+//go:build !unix
+
+package daemon
+
+import "os"
+
+// sigCHLD is nil, SIGCHLD is unix only.
+var sigCHLD os.Signal
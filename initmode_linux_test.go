@@ -0,0 +1,53 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWatchChildren(t *testing.T) {
+	chld := make(chan chan<- os.Signal, 1)
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalNotify(mock.Anything, []os.Signal{syscall.SIGCHLD}).Run(func(c chan<- os.Signal, _ ...os.Signal) { chld <- c }).Once()
+	s.EXPECT().SignalStop(mock.Anything).Twice()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+	d.watchChildren()
+
+	// the child is never waited for, so it stays a zombie once it exits.
+	path, err := exec.LookPath("true")
+	require.NoError(t, err)
+	p, err := os.StartProcess(path, []string{"true"}, &os.ProcAttr{})
+	require.NoError(t, err)
+	pid := strconv.Itoa(p.Pid)
+
+	assert.Eventually(t, func() bool {
+		b, err := os.ReadFile("/proc/" + pid + "/stat")
+		if err != nil {
+			return false
+		}
+		_, state, _, _ := parseProcStat(b)
+		return state == 'Z'
+	}, 5*time.Second, 10*time.Millisecond)
+
+	(<-chld) <- syscall.SIGCHLD
+
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat("/proc/" + pid)
+		return os.IsNotExist(err)
+	}, 5*time.Second, 10*time.Millisecond)
+
+	d.ShutDown()
+	d.Wait()
+}
@@ -0,0 +1,10 @@
+//go:build unix
+
+package daemon
+
+import (
+	"os"
+	"syscall"
+)
+
+var sigCHLD os.Signal = syscall.SIGCHLD
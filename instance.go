@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"crypto/rand"
+	"fmt"
+	"log/slog"
+)
+
+// WithInstanceIDFile writes the instance ID (see `InstanceID()`) to the given file at `Start`.
+func WithInstanceIDFile(path string) DaemonConfigOption {
+	return func(oc *config) {
+		oc.instanceIDFile = path
+	}
+}
+
+// InstanceID returns the unique ID (random UUID) generated for this daemon at `Start`.
+// It is included in every log of the daemon, in its `State()` and in the runs recorded in the state file.
+func (o *Daemon) InstanceID() string { return o.instanceID }
+
+// writeInstanceIDFile writes the instance ID to the configured file, if any.
+func (o *Daemon) writeInstanceIDFile() {
+	if o.config.instanceIDFile == "" {
+		return
+	}
+
+	if err := writeFileAtomic(o.config.instanceIDFile, []byte(o.instanceID+"\n")); err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to write instance id file", slog.String("error", err.Error()))
+	}
+}
+
+// newInstanceID returns a random (version 4) UUID.
+func newInstanceID() string {
+	b := [16]byte{}
+	_, _ = rand.Read(b[:])
+	b[6] = (b[6] & 0x0f) | 0x40 // version 4
+	b[8] = (b[8] & 0x3f) | 0x80 // variant 10
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,43 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInstanceID(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "instance")
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithInstanceIDFile(path), WithLogger(logger(t)), withSTDAPI(s))
+
+	assert.Regexp(t, uuidRegexp, d.InstanceID())
+	assert.Equal(t, d.InstanceID(), d.State().InstanceID)
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, d.InstanceID()+"\n", string(b))
+
+	d.ShutDown()
+	d.Wait()
+}
+
+func TestNewInstanceID(t *testing.T) {
+	a, b := newInstanceID(), newInstanceID()
+	assert.Regexp(t, uuidRegexp, a)
+	assert.Regexp(t, uuidRegexp, b)
+	assert.NotEqual(t, a, b)
+}
+
+var uuidRegexp = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
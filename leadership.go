@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// WithResignLeadershipTimeout sets the timeout of the leadership resignation stage (see `OnResignLeadership`).
+// The default is 5 seconds, zero means no timeout.
+func WithResignLeadershipTimeout(d time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.resignLeadershipTimeout = d
+	}
+}
+
+// OnResignLeadership appends functions that resign any leadership (e.g. leader election leases) the application holds.
+// They are called first when the shutdown starts, before the context cancellation and any shutdown callback,
+// so a new leader can be elected while this instance is still tearing down, instead of after its leases expire.
+// They run in the order they are registered (first in first out), with their own timeout configured using `WithResignLeadershipTimeout`.
+// Errors are logged and do not stop the shutdown.
+func (o *Daemon) OnResignLeadership(f ...func(context.Context) error) {
+	o.onResignMutex.Lock()
+	defer o.onResignMutex.Unlock()
+	o.onResign = append(o.onResign, f...)
+}
+
+// resignLeadership runs the leadership resignation stage.
+func (o *Daemon) resignLeadership(ctx context.Context) {
+	o.onResignMutex.Lock()
+	fns := o.onResign
+	o.onResignMutex.Unlock()
+
+	if len(fns) == 0 {
+		return
+	}
+
+	if o.config.resignLeadershipTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, o.config.resignLeadershipTimeout)
+		defer cancel()
+	}
+
+	for _, f := range fns {
+		if err := f(ctx); err != nil {
+			o.config.logger.ErrorContext(o.ctx, "failed to resign leadership",
+				slog.String("callback", funcName(f)),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestResignLeadership(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithContextCancelPolicy(CancelBeforeCallbacks),
+		WithResignLeadershipTimeout(50*time.Millisecond),
+	)
+
+	order := []string{}
+	d.Defer(func(context.Context) { order = append(order, "callback") })
+	d.OnResignLeadership(
+		func(ctx context.Context) error {
+			assert.NoError(t, d.CTX().Err(), "resignation should happen before the ctx cancellation")
+			order = append(order, "first")
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		func(context.Context) error {
+			order = append(order, "second")
+			return errors.New("lease lost")
+		},
+	)
+
+	start := time.Now()
+	d.ShutDown()
+	d.Wait()
+
+	assert.Equal(t, []string{"first", "second", "callback"}, order)
+	assert.Less(t, time.Since(start), time.Second)
+}
@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+)
+
+type managedListener struct {
+	name    string
+	ln      net.Listener
+	adopted bool
+}
+
+// Listen announces on the local network address, like `net.Listen`, and keeps track of the listener by the given name.
+// Unlike `net.Listen` it takes a name, which identifies the listener when it is handed over: on `Upgrade`, through the systemd
+// file descriptor store and when it is inherited back.
+// The listener is not closed as the very first shutdown step: it is closed after the leadership resignation and the drain delay
+// (see `WithDrainDelay`), since it should keep accepting until the load balancers stop routing to the instance, and before the
+// drain stages and the shutdown callbacks, so no new connections are accepted while they run.
+// If a file descriptor with the same name was passed by systemd (socket activation or the file descriptor store, see `WithSystemdFDStore`)
+// or by the process that started the current one using `Upgrade`, it is adopted instead of creating a new listener.
+// The name is used as systemd's `FDNAME`, so it should only contain ASCII characters, except control characters and ':'.
+func (o *Daemon) Listen(name, network, address string) (net.Listener, error) {
+	if f := takeInheritedFile(name); f != nil {
+		ln, err := net.FileListener(f)
+		_ = f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("adopting inherited listener %q: %w", name, err)
+		}
+
+		o.config.logger.InfoContext(o.ctx, "adopted inherited listener", slog.String("name", name), slog.String("address", ln.Addr().String()))
+		o.addListener(managedListener{name: name, ln: ln, adopted: true})
+
+		return ln, nil
+	}
+
+	ln, err := (&net.ListenConfig{}).Listen(o.ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	o.addListener(managedListener{name: name, ln: ln})
+
+	return ln, nil
+}
+
+// AdoptListener keeps track of an existing listener (e.g. created by a library) by the given name, like the ones created using `Listen`:
+// it is handed over on `Upgrade`, pushed to the systemd file descriptor store if enabled, and closed after the drain delay.
+func (o *Daemon) AdoptListener(name string, ln net.Listener) {
+	o.addListener(managedListener{name: name, ln: ln})
+}
+
+func (o *Daemon) addListener(l managedListener) {
+	o.listenersMutex.Lock()
+	defer o.listenersMutex.Unlock()
+	o.listeners = append(o.listeners, l)
+}
+
+// closeListeners closes every tracked listener, so no new connections are accepted while the drain stages and the shutdown callbacks run.
+// It is called after the drain delay, since the listeners should keep accepting until the load balancers stop routing to the instance.
+func (o *Daemon) closeListeners() {
+	o.listenersMutex.Lock()
+	listeners := o.listeners
+	o.listenersMutex.Unlock()
+
+	for _, l := range listeners {
+		if err := l.ln.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			o.config.logger.WarnContext(o.ctx, "failed to close listener", slog.String("name", l.name), slog.String("error", err.Error()))
+			continue
+		}
+
+		o.config.logger.InfoContext(o.ctx, "listener closed", slog.String("name", l.name))
+	}
+}
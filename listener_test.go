@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCloseListeners(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	ln, err := d.Listen("http", "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	existing, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	d.AdoptListener("existing", existing)
+
+	dial := func(addr net.Addr) error {
+		conn, err := net.DialTimeout("tcp", addr.String(), time.Second)
+		if err == nil {
+			_ = conn.Close()
+		}
+		return err
+	}
+	require.NoError(t, dial(ln.Addr()))
+	require.NoError(t, dial(existing.Addr()))
+
+	var lnErr, existingErr error
+	d.Defer(func(context.Context) {
+		// the listeners are closed before the shutdown callbacks.
+		lnErr, existingErr = dial(ln.Addr()), dial(existing.Addr())
+	})
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.Error(t, lnErr)
+	assert.Error(t, existingErr)
+	_, err = ln.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
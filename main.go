@@ -0,0 +1,25 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+)
+
+// Main replaces the manual Start/Defer/Wait dance of small services: it starts a daemon with the given options, calls setup with the daemon's context
+// and the daemon (to construct the modules and register their teardown), waits for the graceful shutdown and returns the exit code (see `WaitExitCode`):
+// zero for a clean shutdown, non-zero for fatal errors and 128+signum when the process is terminated immediately by the signals (see `WithMaxSignalCount`).
+// If setup returns an error, it is pushed (wrapped in `ErrStartup`) to the fatal errors channel. A panic in setup is handled like `HandleMainPanic`.
+//
+//	func main() {
+//		os.Exit(daemon.Main(context.Background(), setup))
+//	}
+func Main(ctx context.Context, setup func(ctx context.Context, d *Daemon) error, opts ...DaemonConfigOption) int {
+	d := Start(ctx, opts...)
+	defer HandleMainPanic(d)
+
+	if err := setup(d.CTX(), d); err != nil {
+		d.pushFatalError(fmt.Errorf("%w: %w", ErrStartup, err))
+	}
+
+	return d.WaitExitCode()
+}
@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMainHelper(t *testing.T) {
+	t.Run("clean shutdown", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		stopped := false
+		code := Main(context.Background(), func(_ context.Context, d *Daemon) error {
+			d.Defer(func(context.Context) { stopped = true })
+			d.signalCh <- syscall.SIGTERM
+
+			return nil
+		}, WithLogger(logger(t)), withSTDAPI(s))
+
+		assert.Equal(t, 0, code)
+		assert.True(t, stopped)
+	})
+
+	t.Run("setup error", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		var d *Daemon
+		code := Main(context.Background(), func(_ context.Context, dmn *Daemon) error {
+			d = dmn
+			return errors.New("connecting to the database")
+		}, WithLogger(logger(t)), withSTDAPI(s))
+
+		assert.Equal(t, 1, code)
+		assert.ErrorIs(t, d.ShutdownCause(), ErrStartup)
+	})
+
+	t.Run("immediate termination", func(t *testing.T) {
+		ctx, cnl := context.WithCancel(context.Background())
+		defer cnl()
+
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+		s.EXPECT().OSExit(128 + int(syscall.SIGTERM)).Run(func(int) { cnl() }).Once()
+
+		code := Main(ctx, func(_ context.Context, d *Daemon) error {
+			// slow shutdown
+			d.Defer(func(context.Context) { sleep(ctx, time.Minute) })
+
+			go func() {
+				d.signalCh <- syscall.SIGTERM
+				d.signalCh <- syscall.SIGTERM
+			}()
+
+			return nil
+		}, WithMaxSignalCount(2), WithLogger(logger(t)), withSTDAPI(s))
+
+		assert.Equal(t, 128+int(syscall.SIGTERM), code)
+	})
+}
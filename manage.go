@@ -0,0 +1,55 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrStartup is pushed (wrapped) to the fatal errors channel when a resource constructed using `Manage` fails to start.
+var ErrStartup = errors.New("startup failed")
+
+// Manage constructs a resource by calling newFn with the daemon's context and registers the teardown it returns using `Defer`,
+// so a constructed resource can never miss its teardown. The teardown callback is named after newFn.
+// If newFn fails, the error is returned and it is also pushed (wrapped in `ErrStartup`) to the fatal errors channel in order to shut down the daemon.
+func Manage[T any](d *Daemon, newFn func(context.Context) (T, func(context.Context), error)) (T, error) {
+	v, teardown, err := newFn(d.CTX())
+	if err != nil {
+		err = fmt.Errorf("%w: %s: %w", ErrStartup, funcName(newFn), err)
+		d.pushFatalError(err)
+
+		return v, err
+	}
+
+	d.deferNamed(funcName(newFn), teardown)
+
+	return v, nil
+}
+
+// Bracket acquires a resource by calling setup with the daemon's context and, only if setup succeeds, registers the teardown (release) it returns using `Defer`.
+// It expresses "acquire now, release on shutdown" in a single step, so the teardown is always registered right after its setup. The teardown callback is named after setup.
+// Unlike `Manage`, the error of setup is only returned and not pushed to the fatal errors channel.
+func Bracket(d *Daemon, setup func(context.Context) (func(context.Context), error)) error {
+	teardown, err := setup(d.CTX())
+	if err != nil {
+		return err
+	}
+
+	d.deferNamed(funcName(setup), teardown)
+
+	return nil
+}
+
+// deferNamed pushes the teardown, if not nil, to the shutdown callbacks with the given name.
+func (o *Daemon) deferNamed(name string, teardown func(context.Context)) {
+	if teardown == nil {
+		return
+	}
+
+	o.onShutDownMutex.Lock()
+	defer o.onShutDownMutex.Unlock()
+	o.onShutDown = pushFront(o.onShutDown, callback{
+		name: name,
+		fn:   func(ctx context.Context, _ CallbackInfo) { teardown(ctx) },
+	})
+}
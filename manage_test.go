@@ -0,0 +1,91 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type resource struct {
+	closed bool
+}
+
+func newResource(context.Context) (*resource, func(context.Context), error) {
+	r := &resource{}
+	return r, func(context.Context) { r.closed = true }, nil
+}
+
+func newBrokenResource(context.Context) (*resource, func(context.Context), error) {
+	return nil, nil, errors.New("connection refused")
+}
+
+func TestManage(t *testing.T) {
+	t.Run("teardown registered", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		// we specifically want a context that will not get cancelled at the end of the test
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		r, err := Manage(d, newResource)
+		require.NoError(t, err)
+		assert.Equal(t, "daemon.newResource", d.onShutDown[0].name)
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.True(t, r.closed)
+	})
+
+	t.Run("startup failure", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		// we specifically want a context that will not get cancelled at the end of the test
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		r, err := Manage(d, newBrokenResource)
+		require.ErrorIs(t, err, ErrStartup)
+		assert.Nil(t, r)
+
+		d.Wait()
+
+		assert.Equal(t, ReasonFatalError, d.State().Reason)
+	})
+}
+
+func TestBracket(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	order := []string{}
+	acquire := func(name string) func(context.Context) (func(context.Context), error) {
+		return func(context.Context) (func(context.Context), error) {
+			return func(context.Context) { order = append(order, name) }, nil
+		}
+	}
+
+	require.NoError(t, Bracket(d, acquire("first")))
+	require.EqualError(t, Bracket(d, func(context.Context) (func(context.Context), error) {
+		return func(context.Context) { order = append(order, "failed") }, errors.New("connection refused")
+	}), "connection refused")
+	require.NoError(t, Bracket(d, acquire("second")))
+
+	// a failed setup does not shut down the daemon.
+	assert.Equal(t, StatusRunning, d.State().Status)
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.Equal(t, []string{"second", "first"}, order)
+}
@@ -0,0 +1,33 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+)
+
+// WithSignalMeaning gives an operational meaning to a signal: the shutdown it initiates gets the label as its `Reason`,
+// a `*SignalError` as its cause (see `ShutdownCause`), and `WaitExitCode` returns the given exit code.
+// For example `WithSignalMeaning(syscall.SIGTERM, "deploy", 0)` and `WithSignalMeaning(syscall.SIGINT, "operator", 130)`.
+func WithSignalMeaning(sig os.Signal, label string, exitCode int) DaemonConfigOption {
+	return func(oc *config) {
+		if oc.signalMeanings == nil {
+			oc.signalMeanings = map[os.Signal]signalMeaning{}
+		}
+		oc.signalMeanings[sig] = signalMeaning{label: label, exitCode: exitCode}
+	}
+}
+
+type signalMeaning struct {
+	label    string
+	exitCode int
+}
+
+// SignalError is the cause of a shutdown initiated by a signal that has a meaning configured using `WithSignalMeaning`.
+type SignalError struct {
+	Signal os.Signal
+	Label  string
+}
+
+func (e *SignalError) Error() string {
+	return fmt.Sprintf("%s signal received (%s)", e.Label, e.Signal)
+}
@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSignalMeaning(t *testing.T) {
+	tests := map[string]struct {
+		sig              os.Signal
+		expectedReason   Reason
+		expectedCause    error
+		expectedExitCode int
+	}{
+		"with meaning": {
+			sig:              os.Interrupt,
+			expectedReason:   "operator",
+			expectedCause:    &SignalError{Signal: os.Interrupt, Label: "operator"},
+			expectedExitCode: 130,
+		},
+		"without meaning": {
+			sig:            os.Kill,
+			expectedReason: ReasonSignal,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			d := Start(
+				context.Background(),
+				WithLogger(logger(t)),
+				withSTDAPI(s),
+				WithSignalMeaning(os.Interrupt, "operator", 130),
+			)
+
+			d.signalCh <- tc.sig
+
+			assert.Equal(t, tc.expectedExitCode, d.WaitExitCode())
+			assert.Equal(t, tc.expectedReason, d.State().Reason)
+			assert.Equal(t, tc.expectedCause, d.ShutdownCause())
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package daemon
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// WithMemoryPressureWatch polls the cgroup v2 memory controller of the process every interval and initiates a graceful shutdown
+// before the kernel OOM killer terminates the process. The shutdown is initiated when:
+//
+//	a. The `high` or `max` counters of `memory.events` increase, which means that the memory usage hit the configured limits.
+//	b. The `full avg10` value of `memory.pressure` reaches the given threshold percentage (zero disables this check).
+//
+// It is only supported on linux with cgroup v2; on any other platform it is a no-op.
+func WithMemoryPressureWatch(interval time.Duration, threshold float64) DaemonConfigOption {
+	return func(oc *config) {
+		oc.memoryPressureInterval = interval
+		oc.memoryPressureThreshold = threshold
+	}
+}
+
+type memoryStats struct {
+	high      uint64
+	max       uint64
+	fullAvg10 float64
+}
+
+// startMemoryPressureWatch spawns a go routine that polls the cgroup memory stats, if configured.
+func (o *Daemon) startMemoryPressureWatch() {
+	if o.config.memoryPressureInterval <= 0 {
+		return
+	}
+
+	if o.config.cgroupDir == "" {
+		o.config.logger.WarnContext(o.ctx, "memory pressure watch is not supported on this platform")
+		return
+	}
+
+	base, err := readMemoryStats(o.config.cgroupDir)
+	if err != nil {
+		o.config.logger.WarnContext(o.ctx, "memory pressure watch disabled", slog.String("error", err.Error()))
+		return
+	}
+
+	go o.poll(o.config.memoryPressureInterval, func() bool {
+		st, err := readMemoryStats(o.config.cgroupDir)
+		if err != nil {
+			o.config.logger.DebugContext(o.ctx, "failed to read memory stats", slog.String("error", err.Error()))
+			return false
+		}
+
+		threshold := o.config.memoryPressureThreshold
+		if st.high <= base.high && st.max <= base.max && (threshold <= 0 || st.fullAvg10 < threshold) {
+			return false
+		}
+
+		o.config.logger.WarnContext(o.ctx, "memory pressure detected",
+			slog.Uint64("high_events", st.high-base.high),
+			slog.Uint64("max_events", st.max-base.max),
+			slog.Float64("full_avg10", st.fullAvg10),
+		)
+		o.shutDownWith(ReasonMemoryPressure, nil)
+
+		return true
+	})
+}
+
+// readMemoryStats reads the `memory.events` and the (optional) `memory.pressure` files of the given cgroup directory.
+func readMemoryStats(dir string) (memoryStats, error) {
+	st := memoryStats{}
+
+	events, err := os.ReadFile(filepath.Join(dir, "memory.events"))
+	if err != nil {
+		return st, err
+	}
+
+	sc := bufio.NewScanner(bytes.NewReader(events))
+	for sc.Scan() {
+		key, value, _ := strings.Cut(sc.Text(), " ")
+		switch key {
+		case "high":
+			st.high, _ = strconv.ParseUint(value, 10, 64)
+		case "max":
+			st.max, _ = strconv.ParseUint(value, 10, 64)
+		}
+	}
+
+	pressure, err := os.ReadFile(filepath.Join(dir, "memory.pressure"))
+	if errors.Is(err, fs.ErrNotExist) {
+		// PSI might be disabled in kernel.
+		return st, nil
+	}
+	if err != nil {
+		return st, err
+	}
+
+	sc = bufio.NewScanner(bytes.NewReader(pressure))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 || fields[0] != "full" {
+			continue
+		}
+		for _, f := range fields[1:] {
+			if v, found := strings.CutPrefix(f, "avg10="); found {
+				st.fullAvg10, _ = strconv.ParseFloat(v, 64)
+			}
+		}
+	}
+
+	return st, nil
+}
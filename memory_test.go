@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryPressureWatch(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "memory.events"), "low 0\nhigh 0\nmax 0\noom 0\noom_kill 0\n")
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(
+		context.Background(),
+		WithMemoryPressureWatch(5*time.Millisecond, 0),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		withCgroupDir(dir),
+	)
+
+	writeFile(t, filepath.Join(dir, "memory.events"), "low 0\nhigh 3\nmax 0\noom 0\noom_kill 0\n")
+
+	d.Wait()
+
+	assert.Equal(t, ReasonMemoryPressure, d.State().Reason)
+}
+
+func TestReadMemoryStats(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := readMemoryStats(dir)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	writeFile(t, filepath.Join(dir, "memory.events"), "low 1\nhigh 2\nmax 3\noom 0\noom_kill 0\n")
+	st, err := readMemoryStats(dir)
+	require.NoError(t, err)
+	assert.Equal(t, memoryStats{high: 2, max: 3}, st)
+
+	writeFile(t, filepath.Join(dir, "memory.pressure"), "some avg10=1.50 avg60=0.00 avg300=0.00 total=10\nfull avg10=0.75 avg60=0.00 avg300=0.00 total=5\n")
+	st, err = readMemoryStats(dir)
+	require.NoError(t, err)
+	assert.Equal(t, memoryStats{high: 2, max: 3, fullAvg10: 0.75}, st)
+}
+
+// withCgroupDir is used only in testing.
+func withCgroupDir(dir string) DaemonConfigOption {
+	return func(oc *config) {
+		oc.cgroupDir = dir
+	}
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(name, []byte(content), 0o600))
+}
@@ -5,11 +5,90 @@
 package daemon
 
 import (
+	"context"
 	"os"
 
 	mock "github.com/stretchr/testify/mock"
 )
 
+// NewMockFlusher creates a new instance of MockFlusher. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockFlusher(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockFlusher {
+	mock := &MockFlusher{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
+
+// MockFlusher is an autogenerated mock type for the Flusher type
+type MockFlusher struct {
+	mock.Mock
+}
+
+type MockFlusher_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockFlusher) EXPECT() *MockFlusher_Expecter {
+	return &MockFlusher_Expecter{mock: &_m.Mock}
+}
+
+// Flush provides a mock function for the type MockFlusher
+func (_mock *MockFlusher) Flush(ctx context.Context) error {
+	ret := _mock.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Flush")
+	}
+
+	var r0 error
+	if returnFunc, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = returnFunc(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+	return r0
+}
+
+// MockFlusher_Flush_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Flush'
+type MockFlusher_Flush_Call struct {
+	*mock.Call
+}
+
+// Flush is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *MockFlusher_Expecter) Flush(ctx any) *MockFlusher_Flush_Call {
+	return &MockFlusher_Flush_Call{Call: _e.mock.On("Flush", ctx)}
+}
+
+func (_c *MockFlusher_Flush_Call) Run(run func(ctx context.Context)) *MockFlusher_Flush_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		var arg0 context.Context
+		if args[0] != nil {
+			arg0 = args[0].(context.Context)
+		}
+		run(
+			arg0,
+		)
+	})
+	return _c
+}
+
+func (_c *MockFlusher_Flush_Call) Return(err error) *MockFlusher_Flush_Call {
+	_c.Call.Return(err)
+	return _c
+}
+
+func (_c *MockFlusher_Flush_Call) RunAndReturn(run func(ctx context.Context) error) *MockFlusher_Flush_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // newMockstdAPI creates a new instance of mockstdAPI. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
 // The first argument is typically a *testing.T value.
 func newMockstdAPI(t interface {
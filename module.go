@@ -0,0 +1,231 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/trace"
+	"sync"
+)
+
+// ErrModuleGraph is returned by `StartModules` when the registered modules have duplicate names, unknown dependencies or dependency cycles.
+var ErrModuleGraph = errors.New("invalid module graph")
+
+// Module is a component of the application with declared dependencies, registered using `Register`.
+type Module struct {
+	// Name of the module, unique across the registered modules.
+	Name string
+	// DependsOn lists the names of the modules that must be started before and stopped after this one.
+	DependsOn []string
+	// Start is called with the daemon's context (optional).
+	Start func(context.Context) error
+	// Stop is called with the shutdown context (optional).
+	Stop func(context.Context) error
+}
+
+// moduleGraph holds the modules registered using `Register`.
+type moduleGraph struct {
+	mu         sync.Mutex
+	registered []Module
+	next       int
+	started    map[string]bool
+}
+
+// Register adds modules to the daemon's module graph. They are started by `StartModules`, instead of manually encoding their order using `Defer` call order.
+func (o *Daemon) Register(m ...Module) {
+	o.modules.mu.Lock()
+	defer o.modules.mu.Unlock()
+	o.modules.registered = append(o.modules.registered, m...)
+}
+
+// StartModules starts the modules registered since the last call, in topological order: a module starts once all of its dependencies have started,
+// so independent branches start concurrently. On shutdown, they are stopped in reverse order (a module stops once all of its dependents have stopped),
+// as a single shutdown callback registered using `Defer`. Only the modules that started successfully are stopped.
+// If the graph is invalid, an `ErrModuleGraph` error is returned and nothing is started. If a module fails to start, its dependents are not started,
+// and the error is returned and also pushed (wrapped in `ErrStartup`) to the fatal errors channel in order to shut down the daemon.
+func (o *Daemon) StartModules() error {
+	o.modules.mu.Lock()
+	defer o.modules.mu.Unlock()
+
+	if err := validateModules(o.modules.registered); err != nil {
+		return err
+	}
+
+	batch := o.modules.registered[o.modules.next:]
+	o.modules.next = len(o.modules.registered)
+	if o.modules.started == nil {
+		o.modules.started = map[string]bool{}
+	}
+
+	ctx, task := trace.NewTask(o.ctx, "daemon.start_modules")
+	defer task.End()
+
+	started, err := startModules(ctx, batch, o.modules.started)
+	for _, m := range started {
+		o.modules.started[m.Name] = true
+	}
+
+	if len(started) > 0 {
+		o.deferNamed("daemon.modules", func(ctx context.Context) { o.stopModules(ctx, started) })
+	}
+
+	if err != nil {
+		o.pushFatalError(err)
+	}
+
+	return err
+}
+
+// startModules starts the batch of modules concurrently, each one once its dependencies have started. It returns the modules that started, in batch order.
+func startModules(ctx context.Context, batch []Module, previous map[string]bool) ([]Module, error) {
+	type run struct {
+		done chan struct{}
+		ok   bool
+	}
+
+	runs := make(map[string]*run, len(batch))
+	for _, m := range batch {
+		runs[m.Name] = &run{done: make(chan struct{})}
+	}
+
+	errsMutex := sync.Mutex{}
+	errs := []error{}
+	fail := func(err error) {
+		errsMutex.Lock()
+		defer errsMutex.Unlock()
+		errs = append(errs, err)
+	}
+
+	wg := sync.WaitGroup{}
+	for _, m := range batch {
+		wg.Go(func() {
+			r := runs[m.Name]
+			defer close(r.done)
+
+			for _, dep := range m.DependsOn {
+				if dr, found := runs[dep]; found {
+					<-dr.done
+					if !dr.ok {
+						return
+					}
+				} else if !previous[dep] {
+					fail(fmt.Errorf("%w: %s: dependency %s is not started", ErrStartup, m.Name, dep))
+					return
+				}
+			}
+
+			if m.Start != nil {
+				var err error
+				trace.WithRegion(ctx, "module_"+m.Name, func() { err = m.Start(ctx) })
+				if err != nil {
+					fail(fmt.Errorf("%w: %s: %w", ErrStartup, m.Name, err))
+					return
+				}
+			}
+
+			r.ok = true
+		})
+	}
+	wg.Wait()
+
+	started := make([]Module, 0, len(batch))
+	for _, m := range batch {
+		if runs[m.Name].ok {
+			started = append(started, m)
+		}
+	}
+
+	return started, errors.Join(errs...)
+}
+
+// stopModules stops the modules concurrently, each one once its dependents have stopped. Errors are logged.
+func (o *Daemon) stopModules(ctx context.Context, modules []Module) {
+	stopped := make(map[string]chan struct{}, len(modules))
+	for _, m := range modules {
+		stopped[m.Name] = make(chan struct{})
+	}
+
+	dependents := make(map[string][]string, len(modules))
+	for _, m := range modules {
+		for _, dep := range m.DependsOn {
+			if _, found := stopped[dep]; found {
+				dependents[dep] = append(dependents[dep], m.Name)
+			}
+		}
+	}
+
+	wg := sync.WaitGroup{}
+	for _, m := range modules {
+		wg.Go(func() {
+			defer close(stopped[m.Name])
+
+			for _, d := range dependents[m.Name] {
+				<-stopped[d]
+			}
+
+			if m.Stop == nil {
+				return
+			}
+
+			if err := m.Stop(ctx); err != nil {
+				o.config.logger.ErrorContext(ctx, "failed to stop module", slog.String("module", m.Name), slog.String("error", err.Error()))
+			}
+		})
+	}
+	wg.Wait()
+}
+
+// validateModules checks that the module names are unique, the dependencies are registered and there are no dependency cycles.
+func validateModules(modules []Module) error {
+	byName := make(map[string]Module, len(modules))
+	for _, m := range modules {
+		if _, found := byName[m.Name]; found {
+			return fmt.Errorf("%w: duplicate module %s", ErrModuleGraph, m.Name)
+		}
+		byName[m.Name] = m
+	}
+
+	for _, m := range modules {
+		for _, dep := range m.DependsOn {
+			if _, found := byName[dep]; !found {
+				return fmt.Errorf("%w: module %s depends on unknown module %s", ErrModuleGraph, m.Name, dep)
+			}
+		}
+	}
+
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make(map[string]int, len(modules))
+
+	var visit func(name string) error
+	visit = func(name string) error {
+		switch state[name] {
+		case visiting:
+			return fmt.Errorf("%w: dependency cycle through module %s", ErrModuleGraph, name)
+		case visited:
+			return nil
+		}
+
+		state[name] = visiting
+		for _, dep := range byName[name].DependsOn {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		state[name] = visited
+
+		return nil
+	}
+
+	for _, m := range modules {
+		if err := visit(m.Name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
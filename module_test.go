@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// moduleRecorder records the order the modules are started and stopped.
+type moduleRecorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *moduleRecorder) module(name string, dependsOn ...string) Module {
+	record := func(event string) func(context.Context) error {
+		return func(context.Context) error {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.events = append(r.events, event+" "+name)
+
+			return nil
+		}
+	}
+
+	return Module{Name: name, DependsOn: dependsOn, Start: record("start"), Stop: record("stop")}
+}
+
+func (r *moduleRecorder) index(event string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i, e := range r.events {
+		if e == event {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func TestModules(t *testing.T) {
+	t.Run("topological order", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		r := &moduleRecorder{}
+		d.Register(
+			r.module("http", "service"),
+			r.module("service", "db", "cache"),
+			r.module("db"),
+			r.module("cache"),
+		)
+		require.NoError(t, d.StartModules())
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.Len(t, r.events, 8)
+		assert.Less(t, r.index("start db"), r.index("start service"))
+		assert.Less(t, r.index("start cache"), r.index("start service"))
+		assert.Less(t, r.index("start service"), r.index("start http"))
+
+		assert.Less(t, r.index("stop http"), r.index("stop service"))
+		assert.Less(t, r.index("stop service"), r.index("stop db"))
+		assert.Less(t, r.index("stop service"), r.index("stop cache"))
+	})
+
+	t.Run("start failure", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		errBoom := errors.New("boom")
+		r := &moduleRecorder{}
+		broken := r.module("db")
+		broken.Start = func(context.Context) error { return errBoom }
+		d.Register(broken, r.module("service", "db"), r.module("cache"))
+
+		err := d.StartModules()
+		require.ErrorIs(t, err, ErrStartup)
+		require.ErrorIs(t, err, errBoom)
+
+		d.Wait()
+
+		assert.Equal(t, ReasonFatalError, d.reason)
+		// only the modules that started are stopped.
+		assert.ElementsMatch(t, []string{"start cache", "stop cache"}, r.events)
+	})
+}
+
+func TestValidateModules(t *testing.T) {
+	r := &moduleRecorder{}
+
+	tests := map[string]struct {
+		modules   []Module
+		expectErr string
+	}{
+		"valid": {
+			modules: []Module{r.module("a"), r.module("b", "a"), r.module("c", "a", "b")},
+		},
+		"duplicate": {
+			modules:   []Module{r.module("a"), r.module("a")},
+			expectErr: "invalid module graph: duplicate module a",
+		},
+		"unknown dependency": {
+			modules:   []Module{r.module("a", "b")},
+			expectErr: "invalid module graph: module a depends on unknown module b",
+		},
+		"cycle": {
+			modules:   []Module{r.module("a", "c"), r.module("b", "a"), r.module("c", "b")},
+			expectErr: "invalid module graph: dependency cycle through module a",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := validateModules(tc.modules)
+			if tc.expectErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrModuleGraph)
+			assert.EqualError(t, err, tc.expectErr)
+		})
+	}
+}
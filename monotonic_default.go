@@ -0,0 +1,6 @@
+//go:build !linux
+
+package daemon
+
+// monotonicUsec returns zero, since `MONOTONIC_USEC` is only meaningful to systemd, which is linux only.
+func monotonicUsec() int64 { return 0 }
@@ -0,0 +1,19 @@
+package daemon
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// clockMonotonic is CLOCK_MONOTONIC, the clock systemd expects in `MONOTONIC_USEC`.
+const clockMonotonic = 1
+
+// monotonicUsec returns the current CLOCK_MONOTONIC time in microseconds, or zero if it can not be read.
+func monotonicUsec() int64 {
+	ts := syscall.Timespec{}
+	if _, _, errno := syscall.Syscall(syscall.SYS_CLOCK_GETTIME, clockMonotonic, uintptr(unsafe.Pointer(&ts)), 0); errno != 0 {
+		return 0
+	}
+
+	return ts.Nano() / 1000
+}
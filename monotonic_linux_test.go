@@ -0,0 +1,14 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMonotonicUsec(t *testing.T) {
+	a := monotonicUsec()
+	b := monotonicUsec()
+	assert.Positive(t, a)
+	assert.GreaterOrEqual(t, b, a)
+}
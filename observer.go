@@ -0,0 +1,46 @@
+package daemon
+
+import (
+	"context"
+	"time"
+)
+
+// observer is notified about the progress of the shutdown. Every hook is optional.
+type observer struct {
+	shutdownStarted  func(ctx context.Context, reason Reason)
+	callbackStarted  func(ctx context.Context, info CallbackInfo)
+	callbackFinished func(ctx context.Context, info CallbackInfo, elapsed time.Duration)
+	shutdownFinished func(ctx context.Context, elapsed time.Duration)
+}
+
+func (o *Daemon) notifyShutdownStarted(ctx context.Context, reason Reason) {
+	for _, ob := range o.config.observers {
+		if ob.shutdownStarted != nil {
+			ob.shutdownStarted(ctx, reason)
+		}
+	}
+}
+
+func (o *Daemon) notifyCallbackStarted(ctx context.Context, info CallbackInfo) {
+	for _, ob := range o.config.observers {
+		if ob.callbackStarted != nil {
+			ob.callbackStarted(ctx, info)
+		}
+	}
+}
+
+func (o *Daemon) notifyCallbackFinished(ctx context.Context, info CallbackInfo, elapsed time.Duration) {
+	for _, ob := range o.config.observers {
+		if ob.callbackFinished != nil {
+			ob.callbackFinished(ctx, info, elapsed)
+		}
+	}
+}
+
+func (o *Daemon) notifyShutdownFinished(ctx context.Context, elapsed time.Duration) {
+	for _, ob := range o.config.observers {
+		if ob.shutdownFinished != nil {
+			ob.shutdownFinished(ctx, elapsed)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package daemon
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/debug"
+	"time"
+)
+
+// WithPanicGrace sets the grace period of the emergency shutdown performed by `HandleMainPanic`. The default is 5 seconds.
+func WithPanicGrace(d time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.panicGrace = d
+	}
+}
+
+// WithPanicHandler sets a function to report the panics of the shutdown callbacks and the drain stage hooks, e.g. to an error tracker.
+// A panicking callback does not abort the rest of the shutdown either way: the panic is recovered, logged and returned by `Err` as the callback's error.
+func WithPanicHandler(h func(recovered any, stack []byte)) DaemonConfigOption {
+	return func(oc *config) {
+		oc.panicHandler = h
+	}
+}
+
+// recoverCallback recovers the panic of the named shutdown callback, if any, so the rest of the shutdown runs. It should be deferred.
+func (o *Daemon) recoverCallback(name string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	stack := debug.Stack()
+	o.config.logger.ErrorContext(o.ctx, "shutdown callback panicked",
+		slog.String("callback", name),
+		slog.String("panic", fmt.Sprint(r)),
+		slog.String("stack", string(stack)),
+	)
+	o.addCallbackError(fmt.Errorf("%s: panic: %v", name, r))
+
+	if o.config.panicHandler != nil {
+		o.config.panicHandler(r, stack)
+	}
+}
+
+// HandleMainPanic handles a panic in main() and should be deferred right after `Start`:
+//
+//	d := daemon.Start(ctx)
+//	defer daemon.HandleMainPanic(d)
+//
+// On panic, it logs the panic along with its stack trace using the daemon's logger, runs the shutdown with a short emergency grace period (see `WithPanicGrace`),
+// writes the panic report to stderr (and to the file configured using `WithCrashOutput`) and terminates the process with exit code 3.
+// Unlike an unrecovered panic, the shutdown callbacks get a chance to run.
+func HandleMainPanic(d *Daemon) {
+	r := recover()
+	if r == nil {
+		return
+	}
+
+	d.handlePanic(r, debug.Stack())
+}
+
+func (o *Daemon) handlePanic(r any, stack []byte) {
+	cause := fmt.Errorf("panic: %v", r)
+
+	o.config.logger.ErrorContext(o.ctx, "panic in main",
+		slog.String("panic", fmt.Sprint(r)),
+		slog.String("stack", string(stack)),
+	)
+
+	o.mu.Lock()
+	o.panicked = true
+	o.graceCap = o.config.panicGrace
+	o.mu.Unlock()
+
+	// a panic initiates the shutdown even if the daemon is not armed yet.
+	o.initiateShutdown(ShutdownInfo{Reason: ReasonPanic, Cause: cause})
+	o.Wait()
+
+	o.writePanicReport(cause, stack)
+
+	o.config.stdAPI.OSExit(o.exitCode())
+}
+
+// writePanicReport writes the panic and its stack trace, like the runtime does for unrecovered panics.
+func (o *Daemon) writePanicReport(cause error, stack []byte) {
+	report := fmt.Appendf(nil, "%s [recovered by daemon, instance %s]\n\n%s", cause, o.instanceID, stack)
+
+	_, _ = o.config.panicReport.Write(report)
+
+	if o.config.crashOutput == "" {
+		return
+	}
+
+	f, err := os.OpenFile(o.config.crashOutput, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644) //nolint:gosec // the path is given by the configuration.
+	if err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to open crash output file", slog.String("error", err.Error()))
+		return
+	}
+	defer f.Close()
+
+	_, _ = f.Write(report)
+}
@@ -0,0 +1,90 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestHandleMainPanic(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+	s.EXPECT().OSExit(3).Once()
+
+	report := &bytes.Buffer{}
+	d := Start(
+		context.Background(),
+		WithPanicGrace(50*time.Millisecond),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		func(oc *config) { oc.panicReport = report },
+	)
+
+	var deadline time.Time
+	d.Defer(func(ctx context.Context) { deadline, _ = ctx.Deadline() })
+
+	func() {
+		defer HandleMainPanic(d)
+		panic("boom")
+	}()
+
+	assert.WithinDuration(t, time.Now().Add(50*time.Millisecond), deadline, 50*time.Millisecond)
+	assert.Equal(t, ReasonPanic, d.State().Reason)
+	assert.EqualError(t, d.ShutdownCause(), "panic: boom")
+	assert.Contains(t, report.String(), "panic: boom [recovered by daemon, instance "+d.InstanceID()+"]")
+	assert.Contains(t, report.String(), "TestHandleMainPanic")
+}
+
+func TestHandleMainPanicNoPanic(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	func() {
+		defer HandleMainPanic(d)
+	}()
+
+	assert.Equal(t, StatusRunning, d.State().Status)
+	d.ShutDown()
+	d.Wait()
+}
+
+func TestCallbackPanicRecovery(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	var (
+		recovered []any
+		stack     []byte
+	)
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithPanicHandler(func(r any, st []byte) {
+			recovered = append(recovered, r)
+			stack = st
+		}),
+	)
+
+	closed := false
+	d.Defer(func(context.Context) { closed = true })
+	d.DeferNamed("db", func(context.Context) { panic("close on closed connection") })
+	d.OnStage(StageFlush, func(context.Context) error { panic("flush") })
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.True(t, closed)
+	assert.Equal(t, []any{"flush", "close on closed connection"}, recovered)
+	assert.Contains(t, string(stack), "TestCallbackPanicRecovery")
+	assert.ErrorContains(t, d.Err(), "db: panic: close on closed connection")
+}
@@ -0,0 +1,37 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+// WithAdditionalParents adds more parent contexts, besides the one given to `Start`: the graceful shutdown is initiated, with `ReasonParentContext`,
+// once any of them is done. The shutdown cause is the cause of the context wrapped with its position (1 for the first additional parent),
+// so `errors.Is` still matches the original cause. Unlike the parent given to `Start`, they are not used as parent of `CTX()` or of the shutdown callbacks' context.
+func WithAdditionalParents(parents ...context.Context) DaemonConfigOption {
+	return func(oc *config) {
+		oc.additionalParents = append(oc.additionalParents, parents...)
+	}
+}
+
+// watchAdditionalParents spawns a go routine per additional parent context that initiates the shutdown once the context is done.
+func (o *Daemon) watchAdditionalParents() {
+	for i, parent := range o.config.additionalParents {
+		go func() {
+			select {
+			case <-parent.Done():
+				cause := fmt.Errorf("additional parent context #%d: %w", i+1, context.Cause(parent))
+				o.config.logger.ErrorContext(o.ctx, "additional parent context got canceled",
+					slog.Int("parent", i+1),
+					slog.String("error", parent.Err().Error()),
+					slog.String("cause", cause.Error()),
+				)
+				o.shutDownWith(ReasonParentContext, cause)
+
+			// stop watching
+			case <-o.stopping:
+			}
+		}()
+	}
+}
@@ -0,0 +1,32 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdditionalParents(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	first, cancelFirst := context.WithCancelCause(context.Background())
+	defer cancelFirst(nil)
+	second, cancelSecond := context.WithCancelCause(context.Background())
+
+	d := Start(context.Background(), WithAdditionalParents(first, second), WithLogger(logger(t)), withSTDAPI(s))
+
+	framework := errors.New("framework stopped")
+	cancelSecond(framework)
+	d.Wait()
+
+	assert.Equal(t, ReasonParentContext, d.State().Reason)
+	assert.ErrorIs(t, d.ShutdownCause(), framework)
+	assert.EqualError(t, d.ShutdownCause(), "additional parent context #2: framework stopped")
+	// the parent given to Start is not affected.
+	assert.NoError(t, d.parentCTX.Err())
+}
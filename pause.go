@@ -0,0 +1,89 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+)
+
+var (
+	// ErrNotRunning is returned by `Pause` when the daemon is not running (already paused, or shutting down).
+	ErrNotRunning = errors.New("daemon is not running")
+	// ErrNotPaused is returned by `Resume` when the daemon is not paused.
+	ErrNotPaused = errors.New("daemon is not paused")
+)
+
+// OnPause appends functions to be called when the daemon is paused (e.g. SERVICE_CONTROL_PAUSE of a windows service).
+// They should stop the intake of new work without tearing anything down. They are called in the order they are registered (first in first out).
+func (o *Daemon) OnPause(f ...func(context.Context) error) {
+	o.onPauseMutex.Lock()
+	defer o.onPauseMutex.Unlock()
+	o.onPause = append(o.onPause, f...)
+}
+
+// OnResume appends functions to be called when the daemon is resumed after a pause (e.g. SERVICE_CONTROL_CONTINUE of a windows service).
+// They are called in the order they are registered (first in first out).
+func (o *Daemon) OnResume(f ...func(context.Context) error) {
+	o.onPauseMutex.Lock()
+	defer o.onPauseMutex.Unlock()
+	o.onResume = append(o.onResume, f...)
+}
+
+// Pause calls every function registered using `OnPause` and, if none of them fails, sets the daemon's status to `StatusPaused`.
+// It returns `ErrNotRunning` if the daemon is not running. A pause does not affect the stop conditions.
+func (o *Daemon) Pause(ctx context.Context) error {
+	return o.transition(ctx, StatusRunning, StatusPaused, ErrNotRunning)
+}
+
+// Resume calls every function registered using `OnResume` and, if none of them fails, sets the daemon's status back to `StatusRunning`.
+// It returns `ErrNotPaused` if the daemon is not paused.
+func (o *Daemon) Resume(ctx context.Context) error {
+	return o.transition(ctx, StatusPaused, StatusRunning, ErrNotPaused)
+}
+
+// transition runs the pause or resume hooks and moves the daemon from one status to the other.
+func (o *Daemon) transition(ctx context.Context, from, to Status, errWrongStatus error) error {
+	// serializes pause and resume.
+	o.pauseMutex.Lock()
+	defer o.pauseMutex.Unlock()
+
+	o.mu.Lock()
+	status := o.status
+	o.mu.Unlock()
+
+	if status != from {
+		return errWrongStatus
+	}
+
+	o.onPauseMutex.Lock()
+	fns := o.onResume
+	if to == StatusPaused {
+		fns = o.onPause
+	}
+	o.onPauseMutex.Unlock()
+
+	if err := callAll(ctx, fns); err != nil {
+		return err
+	}
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	// a shutdown may have started meanwhile.
+	if o.status == from {
+		o.status = to
+		o.config.logger.InfoContext(o.ctx, "daemon status changed", slog.String("status", string(to)))
+	}
+
+	return nil
+}
+
+// callAll calls every function sequentially and returns their errors joined.
+func callAll(ctx context.Context, fns []func(context.Context) error) error {
+	errs := make([]error, 0, len(fns))
+	for _, f := range fns {
+		errs = append(errs, f(ctx))
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestPauseResume(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	calls := []string{}
+	failPause := true
+	d.OnPause(func(context.Context) error {
+		calls = append(calls, "pause")
+		if failPause {
+			return errors.New("busy")
+		}
+		return nil
+	})
+	d.OnResume(func(context.Context) error {
+		calls = append(calls, "resume")
+		return nil
+	})
+
+	assert.ErrorIs(t, d.Resume(t.Context()), ErrNotPaused)
+
+	assert.EqualError(t, d.Pause(t.Context()), "busy")
+	assert.Equal(t, StatusRunning, d.State().Status)
+
+	failPause = false
+	assert.NoError(t, d.Pause(t.Context()))
+	assert.Equal(t, StatusPaused, d.State().Status)
+	assert.ErrorIs(t, d.Pause(t.Context()), ErrNotRunning)
+
+	assert.NoError(t, d.Resume(t.Context()))
+	assert.Equal(t, StatusRunning, d.State().Status)
+
+	assert.Equal(t, []string{"pause", "pause", "resume"}, calls)
+
+	// a paused daemon still shuts down.
+	assert.NoError(t, d.Pause(t.Context()))
+	d.ShutDown()
+	d.Wait()
+	assert.Equal(t, StatusStopped, d.State().Status)
+	assert.ErrorIs(t, d.Resume(t.Context()), ErrNotPaused)
+}
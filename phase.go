@@ -0,0 +1,24 @@
+package daemon
+
+import "context"
+
+// Phase is a named shutdown phase. The phases map onto the drain stages (see `OnStage`), so they run sequentially at the beginning of the shutdown,
+// before the shutdown callbacks registered using `Defer`, while the functions of each phase run concurrently.
+type Phase = Stage
+
+const (
+	// PhaseTraffic is the first phase, where the ingress is stopped (e.g. stop accepting connections, deregister from discovery). It is `StageStopIntake`.
+	PhaseTraffic = StageStopIntake
+	// PhaseWorkers is the second phase, where the workers are drained (e.g. finish in-flight jobs, commit offsets). It is `StageFlush`.
+	PhaseWorkers = StageFlush
+	// PhaseStorage is the last phase, where the storage is closed (e.g. close database connections, sync files). It is `StageClose`.
+	PhaseStorage = StageClose
+	// PhaseCallbacks is the phase of the shutdown callbacks registered using `Defer`, after the other phases. It is `StageCallbacks`.
+	PhaseCallbacks = StageCallbacks
+)
+
+// OnShutDownPhase appends functions to be called in the given shutdown phase, modelling the "stop ingress, drain workers, close storage" pattern.
+// It is the same as `OnStage`: errors are logged and do not stop the shutdown, and each phase can have its own budget using `WithStageBudget`.
+func (o *Daemon) OnShutDownPhase(p Phase, f ...func(context.Context) error) {
+	o.OnStage(p, f...)
+}
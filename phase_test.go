@@ -0,0 +1,39 @@
+package daemon
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestShutDownPhases(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	mu := sync.Mutex{}
+	order := []string{}
+	record := func(name string) func(context.Context) error {
+		return func(context.Context) error {
+			mu.Lock()
+			defer mu.Unlock()
+			order = append(order, name)
+			return nil
+		}
+	}
+
+	d.OnShutDownPhase(PhaseStorage, record("storage"))
+	d.OnShutDownPhase(PhaseWorkers, record("workers"), record("workers"))
+	d.OnShutDownPhase(PhaseTraffic, record("traffic"))
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.Equal(t, []string{"traffic", "workers", "workers", "storage"}, order)
+	assert.Equal(t, "stop_intake", PhaseTraffic.String())
+}
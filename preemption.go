@@ -0,0 +1,187 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// ErrPreempted is the (wrapped) shutdown cause when a preemption notice is detected by the watch configured using `WithPreemptionWatch`.
+var ErrPreempted = errors.New("preempted")
+
+// PreemptionCheck checks a cloud metadata endpoint for an interruption notice of the instance.
+// It returns a non empty description of the notice, if one has been issued.
+type PreemptionCheck func(ctx context.Context, client *http.Client) (string, error)
+
+// WithPreemptionWatch polls, every interval, the given cloud metadata checks (e.g. `AWSSpotInterruption()`, `GCPPreemption()`, `AzurePreemption()`)
+// and initiates a graceful shutdown, with `ReasonPreemption`, once any of them reports an interruption notice.
+// Checks that fail (e.g. the metadata endpoint of another cloud is not reachable) are logged in debug level and retried on the next poll.
+func WithPreemptionWatch(interval time.Duration, checks ...PreemptionCheck) DaemonConfigOption {
+	return func(oc *config) {
+		oc.preemptionInterval = interval
+		oc.preemptionChecks = checks
+	}
+}
+
+// AWSSpotInterruption checks the EC2 instance metadata (IMDSv2) for a spot instance interruption notice, issued two minutes before the interruption.
+func AWSSpotInterruption() PreemptionCheck {
+	return awsSpotInterruption("http://169.254.169.254")
+}
+
+// GCPPreemption checks the compute engine metadata server for the preemption of a spot or preemptible VM.
+func GCPPreemption() PreemptionCheck {
+	return gcpPreemption("http://metadata.google.internal")
+}
+
+// AzurePreemption checks the azure instance metadata service for a scheduled `Preempt` event of a spot VM.
+func AzurePreemption() PreemptionCheck {
+	return azurePreemption("http://169.254.169.254")
+}
+
+func awsSpotInterruption(base string) PreemptionCheck {
+	return func(ctx context.Context, client *http.Client) (string, error) {
+		token, err := metadataGet(ctx, client, http.MethodPut, base+"/latest/api/token", map[string]string{"X-aws-ec2-metadata-token-ttl-seconds": "60"})
+		if err != nil {
+			return "", err
+		}
+
+		body, err := metadataGet(ctx, client, http.MethodGet, base+"/latest/meta-data/spot/instance-action", map[string]string{"X-aws-ec2-metadata-token": string(token)})
+		if errors.Is(err, errMetadataNotFound) {
+			return "", nil
+		}
+		if err != nil {
+			return "", err
+		}
+
+		notice := struct {
+			Action string `json:"action"`
+			Time   string `json:"time"`
+		}{}
+		if err := json.Unmarshal(body, &notice); err != nil {
+			return "", err
+		}
+
+		return fmt.Sprintf("aws spot instance %s at %s", notice.Action, notice.Time), nil
+	}
+}
+
+func gcpPreemption(base string) PreemptionCheck {
+	return func(ctx context.Context, client *http.Client) (string, error) {
+		body, err := metadataGet(ctx, client, http.MethodGet, base+"/computeMetadata/v1/instance/preempted", map[string]string{"Metadata-Flavor": "Google"})
+		if err != nil {
+			return "", err
+		}
+
+		if strings.TrimSpace(string(body)) != "TRUE" {
+			return "", nil
+		}
+
+		return "gcp instance preempted", nil
+	}
+}
+
+func azurePreemption(base string) PreemptionCheck {
+	return func(ctx context.Context, client *http.Client) (string, error) {
+		body, err := metadataGet(ctx, client, http.MethodGet, base+"/metadata/scheduledevents?api-version=2020-07-01", map[string]string{"Metadata": "true"})
+		if err != nil {
+			return "", err
+		}
+
+		events := struct {
+			Events []struct {
+				EventID   string `json:"EventId"`
+				EventType string `json:"EventType"`
+				NotBefore string `json:"NotBefore"`
+			} `json:"Events"`
+		}{}
+		if err := json.Unmarshal(body, &events); err != nil {
+			return "", err
+		}
+
+		for _, e := range events.Events {
+			if e.EventType == "Preempt" {
+				return fmt.Sprintf("azure preempt event %s not before %s", e.EventID, e.NotBefore), nil
+			}
+		}
+
+		return "", nil
+	}
+}
+
+var errMetadataNotFound = errors.New("metadata not found")
+
+// metadataGet performs a request to a metadata endpoint and returns the response body.
+func metadataGet(ctx context.Context, client *http.Client, method, url string, headers map[string]string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, errMetadataNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("metadata endpoint %s responded with %s", url, resp.Status)
+	}
+
+	return body, nil
+}
+
+// startPreemptionWatch spawns a go routine that polls the preemption checks, if configured.
+func (o *Daemon) startPreemptionWatch() {
+	if o.config.preemptionInterval <= 0 || len(o.config.preemptionChecks) == 0 {
+		return
+	}
+
+	// metadata endpoints are link local, so they should never be reached through a proxy.
+	client := &http.Client{
+		Transport: &http.Transport{Proxy: nil},
+		Timeout:   min(o.config.preemptionInterval, defaultMetadataTimeout),
+	}
+
+	go func() {
+		defer client.CloseIdleConnections()
+		o.poll(o.config.preemptionInterval, o.checkPreemption(client))
+	}()
+}
+
+// checkPreemption returns the poll check that runs every preemption check.
+func (o *Daemon) checkPreemption(client *http.Client) func() bool {
+	return func() bool {
+		for _, check := range o.config.preemptionChecks {
+			notice, err := check(o.ctx, client)
+			if err != nil {
+				o.config.logger.DebugContext(o.ctx, "preemption check failed", slog.String("error", err.Error()))
+				continue
+			}
+
+			if notice != "" {
+				o.config.logger.WarnContext(o.ctx, "preemption notice received", slog.String("notice", notice))
+				o.shutDownWith(ReasonPreemption, fmt.Errorf("%w: %s", ErrPreempted, notice))
+				return true
+			}
+		}
+
+		return false
+	}
+}
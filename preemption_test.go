@@ -0,0 +1,103 @@
+package daemon
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreemptionChecks(t *testing.T) {
+	tests := map[string]struct {
+		check          func(base string) PreemptionCheck
+		handler        http.HandlerFunc
+		expectedNotice string
+	}{
+		"aws no notice": {
+			check: awsSpotInterruption,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPut {
+					_, _ = w.Write([]byte("token"))
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+			},
+		},
+		"aws notice": {
+			check: awsSpotInterruption,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPut {
+					_, _ = w.Write([]byte("token"))
+					return
+				}
+				assert.Equal(t, "token", r.Header.Get("X-aws-ec2-metadata-token"))
+				_, _ = w.Write([]byte(`{"action": "terminate", "time": "2017-09-18T08:22:00Z"}`))
+			},
+			expectedNotice: "aws spot instance terminate at 2017-09-18T08:22:00Z",
+		},
+		"gcp no notice": {
+			check:   gcpPreemption,
+			handler: func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("FALSE")) },
+		},
+		"gcp notice": {
+			check: gcpPreemption,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Google", r.Header.Get("Metadata-Flavor"))
+				_, _ = w.Write([]byte("TRUE"))
+			},
+			expectedNotice: "gcp instance preempted",
+		},
+		"azure no notice": {
+			check: azurePreemption,
+			handler: func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte(`{"DocumentIncarnation": 1, "Events": [{"EventId": "1", "EventType": "Freeze"}]}`))
+			},
+		},
+		"azure notice": {
+			check: azurePreemption,
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "true", r.Header.Get("Metadata"))
+				_, _ = w.Write([]byte(`{"DocumentIncarnation": 1, "Events": [{"EventId": "2", "EventType": "Preempt", "NotBefore": "Mon, 19 Sep 2016 18:29:47 GMT"}]}`))
+			},
+			expectedNotice: "azure preempt event 2 not before Mon, 19 Sep 2016 18:29:47 GMT",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(tc.handler)
+			defer srv.Close()
+
+			notice, err := tc.check(srv.URL)(t.Context(), srv.Client())
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedNotice, notice)
+		})
+	}
+}
+
+func TestPreemptionWatch(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) { _, _ = w.Write([]byte("TRUE")) }))
+	defer srv.Close()
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	failing := func(context.Context, *http.Client) (string, error) { return "", assert.AnError }
+
+	d := Start(
+		context.Background(),
+		WithPreemptionWatch(10*time.Millisecond, failing, gcpPreemption(srv.URL)),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	assert.NoError(t, d.WaitTimeout(time.Second))
+	assert.Equal(t, ReasonPreemption, d.State().Reason)
+	assert.ErrorIs(t, d.ShutdownCause(), ErrPreempted)
+}
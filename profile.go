@@ -0,0 +1,52 @@
+package daemon
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime/pprof"
+	"time"
+)
+
+// fatalErrorProfiles are the profiles written by `WithFatalErrorProfiles`.
+var fatalErrorProfiles = []string{"goroutine", "heap"}
+
+// WithFatalErrorProfiles makes the daemon write the goroutine and heap profiles (pprof format) to dir when a fatal error triggers the shutdown,
+// before running the shutdown callbacks, for the post-mortem analysis of crash-triggered shutdowns.
+// The files are named `<instance id>-<time>-<profile>.pprof` and the directory is created if it does not exist.
+func WithFatalErrorProfiles(dir string) DaemonConfigOption {
+	return func(oc *config) {
+		oc.fatalErrorProfilesDir = dir
+	}
+}
+
+// writeFatalErrorProfiles writes the profiles to the configured directory, if the shutdown is triggered by a fatal error.
+func (o *Daemon) writeFatalErrorProfiles(reason Reason) {
+	if o.config.fatalErrorProfilesDir == "" || reason != ReasonFatalError {
+		return
+	}
+
+	if err := os.MkdirAll(o.config.fatalErrorProfilesDir, 0o755); err != nil { //nolint:gosec // profiles are not secrets.
+		o.config.logger.ErrorContext(o.ctx, "failed to create profiles directory", slog.String("error", err.Error()))
+		return
+	}
+
+	prefix := fmt.Sprintf("%s-%s-", o.instanceID, time.Now().UTC().Format("20060102T150405"))
+	for _, name := range fatalErrorProfiles {
+		buf := &bytes.Buffer{}
+		if err := pprof.Lookup(name).WriteTo(buf, 0); err != nil {
+			o.config.logger.ErrorContext(o.ctx, "failed to snapshot profile", slog.String("profile", name), slog.String("error", err.Error()))
+			continue
+		}
+
+		path := filepath.Join(o.config.fatalErrorProfilesDir, prefix+name+".pprof")
+		if err := writeFileAtomic(path, buf.Bytes()); err != nil {
+			o.config.logger.ErrorContext(o.ctx, "failed to write profile", slog.String("profile", name), slog.String("error", err.Error()))
+			continue
+		}
+
+		o.config.logger.InfoContext(o.ctx, "profile written", slog.String("profile", name), slog.String("path", path))
+	}
+}
@@ -0,0 +1,66 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFatalErrorProfiles(t *testing.T) {
+	tests := map[string]struct {
+		fatal    bool
+		expected []string
+	}{
+		"fatal error": {
+			fatal:    true,
+			expected: []string{"goroutine", "heap"},
+		},
+		"manual": {
+			fatal:    false,
+			expected: nil,
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "profiles")
+
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			d := Start(context.Background(), WithFatalErrorProfiles(dir), WithLogger(logger(t)), withSTDAPI(s))
+
+			var entries []os.DirEntry
+			d.Defer(func(context.Context) {
+				// the profiles are written before the callbacks.
+				entries, _ = os.ReadDir(dir)
+			})
+
+			if tc.fatal {
+				d.FatalErrorsChannel() <- errors.New("boom")
+			} else {
+				d.ShutDown()
+			}
+			d.Wait()
+
+			var profiles []string
+			for _, e := range entries {
+				m := regexp.MustCompile(`^` + d.instanceID + `-\d{8}T\d{6}-(\w+)\.pprof$`).FindStringSubmatch(e.Name())
+				require.NotNil(t, m, e.Name())
+				info, err := e.Info()
+				require.NoError(t, err)
+				assert.Positive(t, info.Size())
+				profiles = append(profiles, m[1])
+			}
+			assert.Equal(t, tc.expected, profiles)
+		})
+	}
+}
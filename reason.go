@@ -0,0 +1,94 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"os"
+)
+
+// Reason describes which stop condition initiated the daemon's shutdown.
+type Reason string
+
+const (
+	// ReasonSignal is used when one of the notify signals is received from OS.
+	ReasonSignal Reason = "signal"
+	// ReasonFatalError is used when an error is received in the fatal errors channel.
+	ReasonFatalError Reason = "fatal_error"
+	// ReasonParentContext is used when the parent context given in `Start` is done.
+	ReasonParentContext Reason = "parent_context"
+	// ReasonManual is used when the shutdown is initiated by calling `ShutDown()`.
+	ReasonManual Reason = "manual"
+	// ReasonTTLExpired is used when the max uptime configured using `WithMaxUptime` is reached.
+	ReasonTTLExpired Reason = "ttl_expired"
+	// ReasonScheduled is used when the time given in `ShutdownAt` is reached.
+	ReasonScheduled Reason = "scheduled"
+	// ReasonIdle is used when no activity is reported for the duration configured using `WithIdleShutdown`.
+	ReasonIdle Reason = "idle"
+	// ReasonWatchdog is used when the application misses its heartbeat configured using `WithWatchdog`.
+	ReasonWatchdog Reason = "watchdog"
+	// ReasonMemoryPressure is used when the memory pressure watch configured using `WithMemoryPressureWatch` detects memory pressure.
+	ReasonMemoryPressure Reason = "memory_pressure"
+	// ReasonPreemption is used when a cloud interruption notice is detected by the watch configured using `WithPreemptionWatch`.
+	ReasonPreemption Reason = "preemption"
+	// ReasonGroup is used when the shutdown is propagated by a `Group` because another daemon of the group started shutting down.
+	ReasonGroup Reason = "group"
+	// ReasonPanic is used when a panic in main() is handled by `HandleMainPanic`.
+	ReasonPanic Reason = "panic"
+	// ReasonConsoleBreak is used on windows when the signal was caused by a CTRL_BREAK_EVENT.
+	ReasonConsoleBreak Reason = "console_break"
+	// ReasonConsoleClose is used on windows when the signal was caused by the console window being closed.
+	ReasonConsoleClose Reason = "console_close"
+	// ReasonLogoff is used on windows when the signal was caused by the user logging off.
+	ReasonLogoff Reason = "logoff"
+	// ReasonSystemShutdown is used on windows when the signal was caused by the system shutting down,
+	// or when the service control manager requests a service started using `StartWindowsService` to shut down.
+	ReasonSystemShutdown Reason = "system_shutdown"
+	// ReasonServiceStop is used when the service control manager requests a service started using `StartWindowsService` to stop.
+	ReasonServiceStop Reason = "service_stop"
+	// ReasonUpgrade is used when the process hands its listeners over to a new process started by `Upgrade`.
+	ReasonUpgrade Reason = "upgrade"
+)
+
+type shutdownInfoCTXKeyType string
+
+const shutdownInfoCTXKey = shutdownInfoCTXKeyType("shutdownInfoCTXKey")
+
+// ShutdownInfo describes what initiated the daemon's shutdown.
+type ShutdownInfo struct {
+	// Reason is the stop condition that was met.
+	Reason Reason
+	// Signal is the OS signal received, if the shutdown was initiated by a signal.
+	Signal os.Signal
+	// Cause is the error that caused the shutdown, if any (e.g. the fatal error received).
+	Cause error
+}
+
+// ReasonFromContext returns the shutdown info stored in the context given to the shutdown callbacks (and to the drain stages and the leadership resignation),
+// so a callback can behave differently on a fatal error than on a routine drain. It returns false if ctx is not a shutdown context.
+func ReasonFromContext(ctx context.Context) (ShutdownInfo, bool) {
+	info, ok := ctx.Value(shutdownInfoCTXKey).(ShutdownInfo)
+
+	return info, ok
+}
+
+// ShutdownError is the cause of the cancellation of the daemon's context (`context.Cause(d.CTX())`), describing why the daemon stopped.
+// It unwraps to the cause of the shutdown, if any. When the parent context given in `Start` is done, the cause is the parent's one instead.
+type ShutdownError struct {
+	ShutdownInfo
+}
+
+func (e *ShutdownError) Error() string {
+	msg := "daemon shut down: " + string(e.Reason)
+	if e.Signal != nil {
+		msg += fmt.Sprintf(" (%s)", e.Signal)
+	}
+	if e.Cause != nil {
+		msg += ": " + e.Cause.Error()
+	}
+
+	return msg
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Cause
+}
@@ -0,0 +1,125 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReasonFromContext(t *testing.T) {
+	errFatal := errors.New("error")
+
+	tests := map[string]struct {
+		stop     func(d *Daemon)
+		expected ShutdownInfo
+	}{
+		"signal": {
+			stop:     func(d *Daemon) { d.signalCh <- os.Interrupt },
+			expected: ShutdownInfo{Reason: ReasonSignal, Signal: os.Interrupt},
+		},
+		"fatal error": {
+			stop:     func(d *Daemon) { d.FatalErrorsChannel() <- errFatal },
+			expected: ShutdownInfo{Reason: ReasonFatalError, Cause: errFatal},
+		},
+		"manual": {
+			stop:     func(d *Daemon) { d.ShutDown() },
+			expected: ShutdownInfo{Reason: ReasonManual},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+			assert.Empty(t, d.ShutdownReason())
+
+			var (
+				got   ShutdownInfo
+				found bool
+			)
+			d.Defer(func(ctx context.Context) { got, found = ReasonFromContext(ctx) })
+
+			tc.stop(d)
+			d.Wait()
+
+			assert.True(t, found)
+			assert.Equal(t, tc.expected, got)
+			assert.Equal(t, tc.expected.Reason, d.ShutdownReason())
+		})
+	}
+
+	_, found := ReasonFromContext(context.Background())
+	assert.False(t, found)
+}
+
+func TestContextCause(t *testing.T) {
+	errFatal := errors.New("broker unreachable")
+
+	tests := map[string]struct {
+		stop      func(d *Daemon)
+		expectMsg string
+	}{
+		"signal": {
+			stop:      func(d *Daemon) { d.signalCh <- os.Interrupt },
+			expectMsg: "daemon shut down: signal (interrupt)",
+		},
+		"fatal error": {
+			stop:      func(d *Daemon) { d.FatalErrorsChannel() <- errFatal },
+			expectMsg: "daemon shut down: fatal_error: broker unreachable",
+		},
+		"manual": {
+			stop:      func(d *Daemon) { d.ShutDown() },
+			expectMsg: "daemon shut down: manual",
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+			tc.stop(d)
+			d.Wait()
+
+			cause := context.Cause(d.CTX())
+			assert.ErrorIs(t, d.CTX().Err(), context.Canceled)
+			assert.EqualError(t, cause, tc.expectMsg)
+
+			var shutdownErr *ShutdownError
+			assert.ErrorAs(t, cause, &shutdownErr)
+			assert.Equal(t, d.ShutdownReason(), shutdownErr.Reason)
+		})
+	}
+
+	t.Run("unwraps to the fatal error", func(t *testing.T) {
+		assert.ErrorIs(t, &ShutdownError{ShutdownInfo{Reason: ReasonFatalError, Cause: errFatal}}, errFatal)
+	})
+}
+
+func TestShutDownWithCause(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	errRotate := errors.New("credentials rotated")
+	d.ShutDownWithCause(errRotate)
+
+	assert.ErrorIs(t, d.WaitErr(), errRotate)
+	assert.Equal(t, ReasonManual, d.ShutdownReason())
+	assert.ErrorIs(t, d.ShutdownCause(), errRotate)
+	assert.ErrorIs(t, context.Cause(d.CTX()), errRotate)
+	assert.EqualError(t, context.Cause(d.CTX()), "daemon shut down: manual: credentials rotated")
+	assert.Equal(t, 0, d.exitCode())
+}
@@ -0,0 +1,86 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/trace"
+)
+
+// ErrReloadFailed is pushed (wrapped) to the fatal errors channel when a reload triggered by the reload signal or by a config file change fails and `WithReloadFailureFatal` is used.
+var ErrReloadFailed = errors.New("reload failed")
+
+// WithReloadSignal sets the signal that triggers `Reload` once reload functions are registered using `OnReload`.
+// The default is SIGHUP (on platforms that have it), nil disables the reload signal.
+func WithReloadSignal(sig os.Signal) DaemonConfigOption {
+	return func(oc *config) {
+		oc.reloadSignal = sig
+	}
+}
+
+// WithReloadFailureFatal makes a failed reload triggered by the reload signal or by a config file change (see `WithConfigWatch`) shut the daemon down, instead of only being logged.
+func WithReloadFailureFatal() DaemonConfigOption {
+	return func(oc *config) {
+		oc.reloadFailureFatal = true
+	}
+}
+
+// OnReload appends functions to be called when a reload is requested (e.g. through the control socket or the reload signal, see `WithReloadSignal`).
+// Reload functions will be called in the order they are registered (first in first out).
+func (o *Daemon) OnReload(f ...func(context.Context) error) {
+	o.onReloadMutex.Lock()
+	first := len(o.onReload) == 0
+	o.onReload = append(o.onReload, f...)
+	o.onReloadMutex.Unlock()
+
+	if first && len(f) > 0 && o.config.reloadSignal != nil {
+		o.OnSignal(o.config.reloadSignal, o.reloadSignalHandler)
+	}
+}
+
+// Reload calls every registered reload function sequentially and returns their errors joined.
+// If `WithSystemdNotify` is used, systemd is notified with `RELOADING=1` before and `READY=1` after the reload (Type=notify-reload services).
+func (o *Daemon) Reload(ctx context.Context) error {
+	ctx, task := trace.NewTask(ctx, "daemon.reload")
+	defer task.End()
+
+	o.onReloadMutex.Lock()
+	fns := o.onReload
+	o.onReloadMutex.Unlock()
+
+	o.notifySystemdReloading()
+
+	err := callAll(ctx, fns)
+	o.notifySystemdReloaded(err)
+
+	return err
+}
+
+// reloadSignalHandler runs `Reload` in a goroutine tracked by the daemon, so the application keeps running while it reloads.
+// A reload signal received while a reload is in progress is ignored.
+func (o *Daemon) reloadSignalHandler(ctx context.Context) {
+	if !o.reloading.CompareAndSwap(false, true) {
+		o.config.logger.WarnContext(ctx, "reload already in progress")
+		return
+	}
+
+	o.Run("daemon.reload", func(ctx context.Context) error {
+		defer o.reloading.Store(false)
+
+		o.config.logger.InfoContext(ctx, "reloading")
+		err := o.Reload(ctx)
+		if err == nil {
+			o.config.logger.InfoContext(ctx, "reload done")
+			return nil
+		}
+
+		o.config.logger.ErrorContext(ctx, "reload failed", slog.String("error", err.Error()))
+		if o.config.reloadFailureFatal {
+			o.pushFatalError(fmt.Errorf("%w: %w", ErrReloadFailed, err))
+		}
+
+		return nil
+	})
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package daemon
+
+import "os"
+
+// defaultReloadSignal is nil on platforms without SIGHUP.
+var defaultReloadSignal os.Signal
@@ -0,0 +1,70 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestReloadSignal(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, []os.Signal{os.Interrupt}).Once()
+	s.EXPECT().SignalNotify(mock.Anything, []os.Signal{os.Interrupt, os.Kill}).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithSignalsNotify(os.Interrupt),
+		WithReloadSignal(os.Kill),
+	)
+
+	reloaded := make(chan struct{}, 2)
+	release := make(chan struct{})
+	d.OnReload(func(context.Context) error {
+		reloaded <- struct{}{}
+		<-release
+		return errors.New("bad config")
+	})
+
+	d.signalCh <- os.Kill
+	<-reloaded
+
+	// the reload runs in the background, a second reload signal is ignored while it is in progress.
+	d.signalCh <- os.Kill
+	close(release)
+
+	d.ShutDown()
+	d.Wait()
+	assert.Equal(t, ReasonManual, d.ShutdownReason())
+}
+
+func TestReloadFailureFatal(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Twice()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithSignalsNotify(os.Interrupt),
+		WithReloadSignal(os.Kill),
+		WithReloadFailureFatal(),
+	)
+
+	errBadConfig := errors.New("bad config")
+	d.OnReload(func(context.Context) error { return errBadConfig })
+
+	d.signalCh <- os.Kill
+	d.Wait()
+
+	assert.Equal(t, ReasonFatalError, d.ShutdownReason())
+	assert.ErrorIs(t, d.ShutdownCause(), ErrReloadFailed)
+	assert.ErrorIs(t, d.ShutdownCause(), errBadConfig)
+}
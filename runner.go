@@ -0,0 +1,221 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrRunnerFailed is pushed (wrapped) to the fatal errors channel when a function started using `Run` returns an error.
+var ErrRunnerFailed = errors.New("runner failed")
+
+// ErrGoroutinePanic is pushed (wrapped) to the fatal errors channel when a function started using `Go` panics.
+var ErrGoroutinePanic = errors.New("goroutine panicked")
+
+// RestartPolicy describes when a function started using `Run` is restarted after it returns.
+type RestartPolicy int
+
+const (
+	// RestartNever never restarts the runner. This is the default policy.
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure restarts the runner when it returns an error.
+	RestartOnFailure
+	// RestartAlways restarts the runner whenever it returns, until the shutdown.
+	RestartAlways
+)
+
+// RunOption configures a function started using `Run`.
+type RunOption func(*runConfig)
+
+type runConfig struct {
+	restartPolicy RestartPolicy
+	maxRestarts   int
+	backoffBase   time.Duration
+	backoffMax    time.Duration
+}
+
+// WithRestartPolicy restarts the runner according to the policy, up to maxRestarts times (zero means no limit), before its error becomes fatal.
+// Restarts are delayed by an exponential backoff (see `WithRestartBackoff`) and logged along with the number of restarts of the runner so far.
+func WithRestartPolicy(p RestartPolicy, maxRestarts int) RunOption {
+	return func(rc *runConfig) {
+		rc.restartPolicy = p
+		rc.maxRestarts = maxRestarts
+	}
+}
+
+// WithRestartBackoff sets the delay before the first restart of the runner, doubled on every next restart up to maxDelay. The default is 100ms up to 30s.
+func WithRestartBackoff(base, maxDelay time.Duration) RunOption {
+	return func(rc *runConfig) {
+		rc.backoffBase = base
+		rc.backoffMax = maxDelay
+	}
+}
+
+// runners tracks the functions started using `Run`.
+type runners struct {
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	running map[string]int
+}
+
+// Run starts fn in a goroutine tracked by the daemon, with the daemon's context (`CTX()`), so modules do not have to wire their own goroutine to the fatal errors channel.
+// If fn returns an error (other than the cancellation of the daemon's context), it is pushed (wrapped in `ErrRunnerFailed` along with the name) to the fatal errors channel in order to shut down the daemon.
+// The shutdown waits for every runner to return once the context is cancelled (see `WithContextCancelPolicy`), up to the grace period. Runners are not started once the shutdown has begun.
+// A runner that crashes can be restarted, before its error becomes fatal, using `WithRestartPolicy`.
+func (o *Daemon) Run(name string, fn func(ctx context.Context) error, opts ...RunOption) {
+	cnf := runConfig{backoffBase: defaultRestartBackoffBase, backoffMax: defaultRestartBackoffMax}
+	for _, opt := range opts {
+		opt(&cnf)
+	}
+
+	o.runners.mu.Lock()
+	defer o.runners.mu.Unlock()
+
+	select {
+	case <-o.stopping:
+		o.config.logger.WarnContext(o.ctx, "runner not started, shutdown in progress", slog.String("runner", name))
+		return
+	default:
+	}
+
+	if o.runners.running == nil {
+		o.runners.running = map[string]int{}
+	}
+	o.runners.running[name]++
+
+	o.runners.wg.Go(func() {
+		defer o.runnerDone(name)
+
+		err := o.supervise(name, fn, cnf)
+		if err == nil || (errors.Is(err, context.Canceled) && o.ctx.Err() != nil) {
+			return
+		}
+
+		o.pushFatalError(fmt.Errorf("%w: %s: %w", ErrRunnerFailed, name, err))
+	})
+}
+
+// supervise calls fn, restarting it according to the restart policy, and returns the error of its last run.
+func (o *Daemon) supervise(name string, fn func(ctx context.Context) error, cnf runConfig) error {
+	for restarts := 0; ; restarts++ {
+		err := fn(o.ctx)
+
+		restart := cnf.restartPolicy == RestartAlways || (cnf.restartPolicy == RestartOnFailure && err != nil)
+		if !restart || o.ctx.Err() != nil {
+			return err
+		}
+
+		select {
+		case <-o.stopping:
+			// no restart once the shutdown has begun.
+			return err
+		default:
+		}
+
+		if cnf.maxRestarts > 0 && restarts >= cnf.maxRestarts {
+			o.config.logger.ErrorContext(o.ctx, "runner restarts exhausted", slog.String("runner", name), slog.Int("restarts", restarts))
+			return err
+		}
+
+		backoff := crashLoopBackoff(cnf.backoffBase, cnf.backoffMax, restarts)
+		attrs := []any{slog.String("runner", name), slog.Int("restarts", restarts+1), slog.Duration("backoff", backoff)}
+		if err != nil {
+			attrs = append(attrs, slog.String("error", err.Error()))
+		}
+		o.config.logger.WarnContext(o.ctx, "restarting runner", attrs...)
+
+		t := time.NewTimer(backoff)
+		select {
+		case <-t.C:
+		case <-o.stopping:
+			t.Stop()
+			return err
+		}
+	}
+}
+
+// Go runs fn in a goroutine tracked by the daemon, with the daemon's context (`CTX()`). It is a `Run` for background work that does not return an error:
+// the goroutine is named after fn, the shutdown waits for it (see `WithWaitForGoroutines`) and a panic is recovered, logged and pushed
+// (wrapped in `ErrGoroutinePanic`) to the fatal errors channel in order to shut down the daemon, instead of crashing the process.
+func (o *Daemon) Go(fn func(ctx context.Context)) {
+	name := funcName(fn)
+
+	o.Run(name, func(ctx context.Context) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				o.config.logger.ErrorContext(o.ctx, "goroutine panicked",
+					slog.String("goroutine", name),
+					slog.String("panic", fmt.Sprint(r)),
+					slog.String("stack", string(debug.Stack())),
+				)
+				err = fmt.Errorf("%w: %v", ErrGoroutinePanic, r)
+			}
+		}()
+
+		fn(ctx)
+
+		return nil
+	})
+}
+
+// WithWaitForGoroutines makes the shutdown wait for the goroutines started using `Go` and `Run` before running the shutdown callbacks (after the drain stages),
+// instead of after them, up to the grace period. They should stop on their own by then, e.g. because the context gets cancelled before the callbacks
+// (see `CancelBeforeCallbacks`) or because of a drain stage hook.
+func WithWaitForGoroutines() DaemonConfigOption {
+	return func(oc *config) {
+		oc.waitRunnersBeforeCallbacks = true
+	}
+}
+
+func (o *Daemon) runnerDone(name string) {
+	o.runners.mu.Lock()
+	defer o.runners.mu.Unlock()
+
+	o.runners.running[name]--
+	if o.runners.running[name] == 0 {
+		delete(o.runners.running, name)
+	}
+}
+
+// waitRunnersBeforeCallbacks waits for the runners, if configured using `WithWaitForGoroutines`.
+func (o *Daemon) waitRunnersBeforeCallbacks(ctx context.Context) {
+	if o.config.waitRunnersBeforeCallbacks {
+		o.waitRunners(ctx)
+	}
+}
+
+// waitRunners waits for every runner to return, or until ctx is done in which case the names of the runners still running are logged.
+func (o *Daemon) waitRunners(ctx context.Context) {
+	// no runner can be started after the shutdown has begun, so holding the mutex once ensures the wait group is not added to while waiting.
+	o.runners.mu.Lock()
+	if len(o.runners.running) == 0 {
+		o.runners.mu.Unlock()
+		return
+	}
+	o.runners.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		o.runners.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		o.runners.mu.Lock()
+		names := make([]string, 0, len(o.runners.running))
+		for name := range o.runners.running {
+			names = append(names, name)
+		}
+		o.runners.mu.Unlock()
+		slices.Sort(names)
+
+		o.config.logger.WarnContext(o.ctx, "runners did not return in time", slog.Any("runners", names))
+	}
+}
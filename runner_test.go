@@ -0,0 +1,222 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRun(t *testing.T) {
+	t.Run("error shuts down", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		errBoom := errors.New("boom")
+		d.Run("consumer", func(context.Context) error { return errBoom })
+
+		var info ShutdownInfo
+		d.Defer(func(ctx context.Context) { info, _ = ReasonFromContext(ctx) })
+		d.Wait()
+
+		assert.Equal(t, ReasonFatalError, info.Reason)
+		assert.ErrorIs(t, info.Cause, ErrRunnerFailed)
+		assert.ErrorIs(t, info.Cause, errBoom)
+		assert.ErrorContains(t, info.Cause, "consumer")
+	})
+
+	t.Run("shutdown waits for runners", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		returned := atomic.Bool{}
+		d.Run("http", func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond)
+			returned.Store(true)
+
+			return ctx.Err()
+		})
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.True(t, returned.Load())
+		assert.Equal(t, 0, d.exitCode())
+
+		// not started once the shutdown has begun.
+		d.Run("late", func(context.Context) error {
+			t.Error("late runner should not be started")
+			return nil
+		})
+	})
+
+	t.Run("runner not returning in time", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), WithShutdownGraceDuration(20*time.Millisecond))
+
+		release := make(chan struct{})
+		d.Run("stuck", func(context.Context) error {
+			<-release
+			return nil
+		})
+
+		d.ShutDown()
+		d.Wait()
+
+		close(release)
+		d.runners.wg.Wait()
+	})
+}
+
+func TestRunRestartPolicy(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	t.Run("on failure up to max restarts", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		runs := atomic.Int64{}
+		d.Run("consumer", func(context.Context) error {
+			runs.Add(1)
+			return errBoom
+		}, WithRestartPolicy(RestartOnFailure, 2), WithRestartBackoff(time.Millisecond, 5*time.Millisecond))
+
+		var info ShutdownInfo
+		d.Defer(func(ctx context.Context) { info, _ = ReasonFromContext(ctx) })
+		d.Wait()
+
+		assert.Equal(t, int64(3), runs.Load())
+		assert.Equal(t, ReasonFatalError, info.Reason)
+		assert.ErrorIs(t, info.Cause, errBoom)
+	})
+
+	t.Run("on failure recovers", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		runs := atomic.Int64{}
+		d.Run("consumer", func(ctx context.Context) error {
+			if runs.Add(1) < 3 {
+				return errBoom
+			}
+			<-ctx.Done()
+
+			return ctx.Err()
+		}, WithRestartPolicy(RestartOnFailure, 5), WithRestartBackoff(time.Millisecond, time.Millisecond))
+
+		assert.Eventually(t, func() bool { return runs.Load() == 3 }, time.Second, time.Millisecond)
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.Equal(t, ReasonManual, d.reason)
+		assert.Equal(t, int64(3), runs.Load())
+	})
+
+	t.Run("always until shutdown", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		runs := atomic.Int64{}
+		d.Run("poller", func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}, WithRestartPolicy(RestartAlways, 0), WithRestartBackoff(time.Millisecond, time.Millisecond))
+
+		assert.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, time.Millisecond)
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.Equal(t, ReasonManual, d.reason)
+	})
+
+	t.Run("never", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		runs := atomic.Int64{}
+		d.Run("once", func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}, WithRestartPolicy(RestartNever, 3))
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.Equal(t, int64(1), runs.Load())
+	})
+}
+
+func TestGo(t *testing.T) {
+	t.Run("panic shuts down", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		d.Go(func(context.Context) { panic("corrupted state") })
+		d.Wait()
+
+		assert.Equal(t, ReasonFatalError, d.ShutdownReason())
+		assert.ErrorIs(t, d.ShutdownCause(), ErrGoroutinePanic)
+		assert.ErrorIs(t, d.ShutdownCause(), ErrRunnerFailed)
+		assert.ErrorContains(t, d.ShutdownCause(), "corrupted state")
+	})
+
+	t.Run("wait before callbacks", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(
+			context.Background(),
+			WithLogger(logger(t)),
+			withSTDAPI(s),
+			WithContextCancelPolicy(CancelBeforeCallbacks),
+			WithWaitForGoroutines(),
+		)
+
+		returned := atomic.Bool{}
+		d.Go(func(ctx context.Context) {
+			<-ctx.Done()
+			time.Sleep(20 * time.Millisecond)
+			returned.Store(true)
+		})
+
+		returnedBeforeCallbacks := false
+		d.Defer(func(context.Context) { returnedBeforeCallbacks = returned.Load() })
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.True(t, returnedBeforeCallbacks)
+	})
+}
@@ -0,0 +1,109 @@
+package daemon
+
+import (
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// EnvTerminationGracePeriod is the environment variable read on kubernetes for the pod's `terminationGracePeriodSeconds`,
+// which is not exposed to the container by default. Its value is either a go duration (e.g. `45s`) or integer seconds.
+const EnvTerminationGracePeriod = "TERMINATION_GRACE_PERIOD_SECONDS"
+
+// WithRuntimeBudgetCheck makes `Start` detect the container runtime the daemon runs in, and the time it allows between the termination signal and the kill:
+//
+//	DAEMON_TERMINATION_BUDGET set: its value.
+//	kubernetes:                    `TERMINATION_GRACE_PERIOD_SECONDS` if set, otherwise 30s.
+//	docker, podman:                10s, the default stop timeout.
+//
+// A warning is logged when the grace period plus the drain delay (see `WithDrainDelay`) exceed that budget, since the shutdown would end in a SIGKILL.
+func WithRuntimeBudgetCheck() DaemonConfigOption {
+	return func(oc *config) {
+		oc.runtimeBudgetCheck = true
+	}
+}
+
+// WithRuntimeBudgetClamp is like `WithRuntimeBudgetCheck`, but also clamps the grace period and the drain delay to fit in the budget.
+// The grace period is shortened first, keeping at least half of the budget, and then the drain delay.
+func WithRuntimeBudgetClamp() DaemonConfigOption {
+	return func(oc *config) {
+		oc.runtimeBudgetCheck = true
+		oc.runtimeBudgetClamp = true
+	}
+}
+
+// checkRuntimeBudget checks the grace period and the drain delay against the container runtime budget, if configured.
+func (o *Daemon) checkRuntimeBudget() {
+	if !o.config.runtimeBudgetCheck {
+		return
+	}
+
+	platform, budget := detectRuntimeBudget(o.config.runtimeRoot)
+	if budget <= 0 {
+		return
+	}
+
+	grace, delay := o.config.shutdownTimeout, o.config.drainDelay
+	// an infinite grace period never fits.
+	if grace > 0 && grace+delay <= budget {
+		return
+	}
+
+	attrs := []any{
+		slog.String("runtime", platform),
+		slog.Duration("budget", budget),
+		slog.Duration("grace", grace),
+		slog.Duration("drain_delay", delay),
+	}
+
+	if !o.config.runtimeBudgetClamp {
+		o.config.logger.WarnContext(o.ctx, "grace period and drain delay exceed the container runtime budget", attrs...)
+		return
+	}
+
+	o.config.shutdownTimeout, o.config.drainDelay = clampToBudget(grace, delay, budget)
+	o.config.logger.WarnContext(o.ctx, "grace period and drain delay clamped to the container runtime budget", append(attrs,
+		slog.Duration("clamped_grace", o.config.shutdownTimeout),
+		slog.Duration("clamped_drain_delay", o.config.drainDelay),
+	)...)
+}
+
+// clampToBudget shortens the grace period (zero means infinite), keeping at least half of the budget, and then the drain delay, to fit in the budget.
+func clampToBudget(grace, delay, budget time.Duration) (time.Duration, time.Duration) {
+	clamped := max(budget-delay, budget/2)
+	if grace > 0 {
+		clamped = min(grace, clamped)
+	}
+
+	return clamped, max(min(delay, budget-clamped), 0)
+}
+
+// detectRuntimeBudget returns the container runtime the process runs in, and its termination budget. It returns zero budget if unknown.
+func detectRuntimeBudget(root string) (string, time.Duration) {
+	if d := envDuration(EnvTerminationBudget); d > 0 {
+		return "configured", d
+	}
+
+	if os.Getenv("KUBERNETES_SERVICE_HOST") != "" {
+		if d := envDuration(EnvTerminationGracePeriod); d > 0 {
+			return "kubernetes", d
+		}
+
+		return "kubernetes", kubernetesTerminationGracePeriod
+	}
+
+	if root == "" {
+		return "", 0
+	}
+
+	if _, err := os.Stat(filepath.Join(root, ".dockerenv")); err == nil {
+		return "docker", dockerStopTimeout
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "run", ".containerenv")); err == nil {
+		return "podman", dockerStopTimeout
+	}
+
+	return "", 0
+}
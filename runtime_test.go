@@ -0,0 +1,96 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDetectRuntimeBudget(t *testing.T) {
+	docker := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(docker, ".dockerenv"), nil, 0o600))
+
+	tests := map[string]struct {
+		env              map[string]string
+		root             string
+		expectedRuntime  string
+		expectedDuration time.Duration
+	}{
+		"none":       {root: t.TempDir()},
+		"docker":     {root: docker, expectedRuntime: "docker", expectedDuration: 10 * time.Second},
+		"kubernetes": {env: map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1"}, root: docker, expectedRuntime: "kubernetes", expectedDuration: 30 * time.Second},
+		"kubernetes grace env": {
+			env:              map[string]string{"KUBERNETES_SERVICE_HOST": "10.0.0.1", EnvTerminationGracePeriod: "60"},
+			expectedRuntime:  "kubernetes",
+			expectedDuration: time.Minute,
+		},
+		"configured": {env: map[string]string{EnvTerminationBudget: "45s"}, root: docker, expectedRuntime: "configured", expectedDuration: 45 * time.Second},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("KUBERNETES_SERVICE_HOST", "")
+			t.Setenv(EnvTerminationGracePeriod, "")
+			t.Setenv(EnvTerminationBudget, "")
+			for k, v := range tc.env {
+				t.Setenv(k, v)
+			}
+
+			rt, budget := detectRuntimeBudget(tc.root)
+			assert.Equal(t, tc.expectedRuntime, rt)
+			assert.Equal(t, tc.expectedDuration, budget)
+		})
+	}
+}
+
+func TestClampToBudget(t *testing.T) {
+	tests := map[string]struct {
+		grace, delay, budget       time.Duration
+		expectedGrace, expectedDly time.Duration
+	}{
+		"grace shortened":        {grace: 20 * time.Second, delay: 5 * time.Second, budget: 10 * time.Second, expectedGrace: 5 * time.Second, expectedDly: 5 * time.Second},
+		"infinite grace":         {grace: 0, delay: 0, budget: 10 * time.Second, expectedGrace: 10 * time.Second, expectedDly: 0},
+		"delay shortened":        {grace: 20 * time.Second, delay: 20 * time.Second, budget: 10 * time.Second, expectedGrace: 5 * time.Second, expectedDly: 5 * time.Second},
+		"short grace kept":       {grace: 2 * time.Second, delay: 20 * time.Second, budget: 10 * time.Second, expectedGrace: 2 * time.Second, expectedDly: 8 * time.Second},
+		"fits without any clamp": {grace: 5 * time.Second, delay: 2 * time.Second, budget: 10 * time.Second, expectedGrace: 5 * time.Second, expectedDly: 2 * time.Second},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			grace, delay := clampToBudget(tc.grace, tc.delay, tc.budget)
+			assert.Equal(t, tc.expectedGrace, grace)
+			assert.Equal(t, tc.expectedDly, delay)
+		})
+	}
+}
+
+func TestRuntimeBudgetClamp(t *testing.T) {
+	t.Setenv("KUBERNETES_SERVICE_HOST", "")
+	t.Setenv(EnvTerminationBudget, "10s")
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithShutdownGraceDuration(30*time.Second),
+		WithDrainDelay(4*time.Second),
+		WithRuntimeBudgetClamp(),
+	)
+
+	assert.Equal(t, 6*time.Second, d.config.shutdownTimeout)
+	assert.Equal(t, 4*time.Second, d.config.drainDelay)
+
+	d.config.drainDelay = 0
+	d.ShutDown()
+	d.Wait()
+}
@@ -0,0 +1,50 @@
+package daemon
+
+import (
+	"log/slog"
+	"time"
+)
+
+// ShutdownAt schedules a graceful shutdown at the given wall-clock time, replacing any previously scheduled one.
+// The scheduled time is visible through `State()` and the schedule can be cancelled using `CancelScheduledShutdown` until it fires.
+func (o *Daemon) ShutdownAt(t time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.status != StatusRunning && o.status != StatusPaused {
+		return
+	}
+
+	if o.scheduleTimer != nil {
+		o.scheduleTimer.Stop()
+	}
+
+	o.scheduledShutdown = t
+	o.scheduleTimer = time.AfterFunc(time.Until(t), func() {
+		o.config.logger.InfoContext(o.ctx, "scheduled shutdown time reached", slog.Time("at", t))
+		o.shutDownWith(ReasonScheduled, nil)
+	})
+
+	o.config.logger.InfoContext(o.ctx, "shutdown scheduled", slog.Time("at", t))
+}
+
+// CancelScheduledShutdown cancels the shutdown scheduled using `ShutdownAt`.
+// It returns false if there was no scheduled shutdown or if it has already fired.
+func (o *Daemon) CancelScheduledShutdown() bool {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.scheduleTimer == nil {
+		return false
+	}
+
+	stopped := o.scheduleTimer.Stop()
+	o.scheduleTimer = nil
+	o.scheduledShutdown = time.Time{}
+
+	if stopped {
+		o.config.logger.InfoContext(o.ctx, "scheduled shutdown cancelled")
+	}
+
+	return stopped
+}
@@ -0,0 +1,51 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestShutdownAt(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	at := time.Now().Add(10 * time.Millisecond)
+	d.ShutdownAt(at)
+	assert.Equal(t, at, d.State().ScheduledShutdown)
+
+	d.Wait()
+
+	st := d.State()
+	assert.Equal(t, StatusStopped, st.Status)
+	assert.Equal(t, ReasonScheduled, st.Reason)
+}
+
+func TestCancelScheduledShutdown(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	assert.False(t, d.CancelScheduledShutdown())
+
+	d.ShutdownAt(time.Now().Add(time.Hour))
+	assert.Equal(t, StatusRunning, d.State().Status)
+	assert.True(t, d.CancelScheduledShutdown())
+	assert.True(t, d.State().ScheduledShutdown.IsZero())
+	assert.False(t, d.CancelScheduledShutdown())
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.Equal(t, ReasonManual, d.State().Reason)
+}
@@ -0,0 +1,61 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+	"time"
+)
+
+// WithSystemdWatchdogCheck sets a health check for the systemd watchdog keepalive.
+// When systemd's watchdog is enabled (`WatchdogSec=`, see `WithSystemdNotify`), the daemon sends `WATCHDOG=1` every half of the watchdog interval,
+// until the shutdown starts. If the check returns an error, the keepalive is skipped, so systemd restarts a service that reports itself unhealthy.
+func WithSystemdWatchdogCheck(check func(context.Context) error) DaemonConfigOption {
+	return func(oc *config) {
+		oc.systemdWatchdogCheck = check
+	}
+}
+
+// startSystemdWatchdog spawns a go routine that sends the systemd watchdog keepalive, if systemd's watchdog is enabled. It stops once the shutdown process starts.
+func (o *Daemon) startSystemdWatchdog() {
+	if !o.config.systemdNotify {
+		return
+	}
+
+	timeout := parseWatchdogUsec(os.Getpid(), os.Getenv("WATCHDOG_PID"), os.Getenv("WATCHDOG_USEC"))
+	if timeout <= 0 {
+		return
+	}
+
+	go o.poll(timeout/2, func() bool {
+		if check := o.config.systemdWatchdogCheck; check != nil {
+			if err := check(o.ctx); err != nil {
+				o.config.logger.ErrorContext(o.ctx, "unhealthy, skipping the systemd watchdog keepalive", slog.String("error", err.Error()))
+				return false
+			}
+		}
+
+		if err := sdNotify("WATCHDOG=1"); err != nil {
+			o.config.logger.ErrorContext(o.ctx, "failed to notify systemd", slog.String("error", err.Error()))
+		}
+
+		return false
+	})
+}
+
+// parseWatchdogUsec parses the values of the `WATCHDOG_PID` and `WATCHDOG_USEC` environment variables.
+// It returns zero if the watchdog is not enabled, or not meant for the process with the given pid.
+func parseWatchdogUsec(pid int, watchdogPID, watchdogUsec string) time.Duration {
+	if watchdogPID != "" && watchdogPID != fmt.Sprint(pid) {
+		return 0
+	}
+
+	usec, err := strconv.ParseInt(watchdogUsec, 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+
+	return time.Duration(usec) * time.Microsecond
+}
@@ -0,0 +1,15 @@
+package daemon
+
+import "errors"
+
+// ErrNotService is returned by `StartWindowsService` when the process is not started by the windows service control manager,
+// e.g. when run from a console, or on any other platform.
+var ErrNotService = errors.New("the process is not running as a windows service")
+
+// WithServicePauseContinue makes the windows service (see `StartWindowsService`) accept the pause and continue requests of the
+// service control manager, which call `Pause` and `Resume`. It is disabled by default, so the service is not shown as pausable.
+func WithServicePauseContinue() DaemonConfigOption {
+	return func(oc *config) {
+		oc.servicePauseContinue = true
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !windows
+
+package daemon
+
+import "context"
+
+// StartWindowsService is like `Start`, for a process that runs as a windows service. It returns `ErrNotService` on every other platform.
+func StartWindowsService(_ context.Context, _ string, _ ...DaemonConfigOption) (*Daemon, error) {
+	return nil, ErrNotService
+}
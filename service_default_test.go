@@ -0,0 +1,16 @@
+//go:build !windows
+
+package daemon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStartWindowsService(t *testing.T) {
+	d, err := StartWindowsService(context.Background(), "svc")
+	assert.ErrorIs(t, err, ErrNotService)
+	assert.Nil(t, d)
+}
@@ -0,0 +1,329 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"slices"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+)
+
+var (
+	advapi32                          = syscall.NewLazyDLL("advapi32.dll")
+	procStartServiceCtrlDispatcherW   = advapi32.NewProc("StartServiceCtrlDispatcherW")
+	procRegisterServiceCtrlHandlerExW = advapi32.NewProc("RegisterServiceCtrlHandlerExW")
+	procSetServiceStatus              = advapi32.NewProc("SetServiceStatus")
+)
+
+const (
+	serviceWin32OwnProcess = 0x10
+
+	serviceStopped         = 1
+	serviceStartPending    = 2
+	serviceStopPending     = 3
+	serviceRunning         = 4
+	serviceContinuePending = 5
+	servicePausePending    = 6
+	servicePaused          = 7
+
+	serviceControlStop        = 0x1
+	serviceControlPause       = 0x2
+	serviceControlContinue    = 0x3
+	serviceControlInterrogate = 0x4
+	serviceControlShutdown    = 0x5
+	serviceControlPreshutdown = 0xf
+
+	serviceAcceptStop           = 0x1
+	serviceAcceptPauseContinue  = 0x2
+	serviceAcceptShutdown       = 0x4
+	serviceAcceptPreshutdown    = 0x100
+	serviceAcceptedWhileRunning = serviceAcceptStop | serviceAcceptShutdown | serviceAcceptPreshutdown
+
+	errorCallNotImplemented             = 120
+	errorServiceSpecificError           = 1066
+	errorFailedServiceControllerConnect = 1063
+)
+
+// serviceCheckpointInterval is how often the progress of a pending operation is reported to the service control manager,
+// which considers the service hung if it does not report within the wait hint.
+const (
+	serviceCheckpointInterval = 5 * time.Second
+	serviceWaitHint           = 2 * serviceCheckpointInterval
+)
+
+// serviceStatus is the SERVICE_STATUS structure.
+type serviceStatus struct {
+	ServiceType             uint32
+	CurrentState            uint32
+	ControlsAccepted        uint32
+	Win32ExitCode           uint32
+	ServiceSpecificExitCode uint32
+	CheckPoint              uint32
+	WaitHint                uint32
+}
+
+// serviceTableEntry is the SERVICE_TABLE_ENTRYW structure.
+type serviceTableEntry struct {
+	name *uint16
+	proc uintptr
+}
+
+// winService is the state of the (single) service the process runs as.
+var winService = struct {
+	once       sync.Once
+	name       *uint16
+	registered chan error
+	stopped    chan struct{}
+
+	mu     sync.Mutex
+	handle uintptr
+	status serviceStatus
+	d      *Daemon
+}{
+	registered: make(chan error, 1),
+	stopped:    make(chan struct{}),
+}
+
+// StartWindowsService is like `Start`, for a process that runs as a windows service with the given name.
+// It connects to the service control manager and translates its control requests to the daemon:
+//
+//	stop:                   initiates the graceful shutdown (`ReasonServiceStop`).
+//	shutdown, pre-shutdown: initiates the graceful shutdown (`ReasonSystemShutdown`).
+//	pause, continue:        calls `Pause` and `Resume`, if enabled using `WithServicePauseContinue`.
+//
+// While the shutdown runs, SERVICE_STOP_PENDING is reported with a checkpoint that advances periodically, and SERVICE_STOPPED,
+// with the daemon's exit code, once it is done. It returns `ErrNotService` if the process was not started by the service control manager,
+// so the caller can fall back to `Start`. It can be called only once per process.
+func StartWindowsService(parentCTX context.Context, name string, opts ...DaemonConfigOption) (*Daemon, error) {
+	err := errors.New("windows service already started")
+	winService.once.Do(func() { err = connectServiceManager(name) })
+	if err != nil {
+		return nil, err
+	}
+
+	d := Start(parentCTX, slices.Concat(opts, []DaemonConfigOption{func(oc *config) {
+		oc.observers = append(oc.observers, serviceObserver())
+	}})...)
+
+	winService.mu.Lock()
+	winService.d = d
+	winService.mu.Unlock()
+	setServiceState(serviceRunning, serviceAccepted(d))
+
+	go func() {
+		code := d.WaitExitCode()
+
+		winService.mu.Lock()
+		if code != 0 {
+			winService.status.Win32ExitCode = errorServiceSpecificError
+			winService.status.ServiceSpecificExitCode = uint32(code) //nolint:gosec // exit codes are small positive numbers.
+		}
+		winService.mu.Unlock()
+
+		// once SERVICE_STOPPED is reported, the service main function returns and the dispatcher after it.
+		setServiceState(serviceStopped, 0)
+		close(winService.stopped)
+	}()
+
+	return d, nil
+}
+
+// connectServiceManager starts the service control dispatcher, on a dedicated thread, and waits until the service main function registers the control handler.
+func connectServiceManager(name string) error {
+	n, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return err
+	}
+	winService.name = n
+
+	go func() {
+		// the dispatcher blocks until the service stops.
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+
+		table := []serviceTableEntry{{name: n, proc: syscall.NewCallback(serviceMain)}, {}}
+		if r, _, err := procStartServiceCtrlDispatcherW.Call(uintptr(unsafe.Pointer(&table[0]))); r == 0 {
+			winService.registered <- err
+		}
+	}()
+
+	err = <-winService.registered
+	if errors.Is(err, syscall.Errno(errorFailedServiceControllerConnect)) {
+		return fmt.Errorf("%w: %w", ErrNotService, err)
+	}
+
+	return err
+}
+
+// serviceMain is the service main function, called by the dispatcher on its own thread. It returns once the service is stopped.
+func serviceMain(_, _ uintptr) uintptr {
+	h, _, err := procRegisterServiceCtrlHandlerExW.Call(uintptr(unsafe.Pointer(winService.name)), syscall.NewCallback(serviceHandler), 0)
+	if h == 0 {
+		winService.registered <- err
+		return 0
+	}
+
+	winService.mu.Lock()
+	winService.handle = h
+	winService.status.ServiceType = serviceWin32OwnProcess
+	winService.mu.Unlock()
+
+	setServiceState(serviceStartPending, 0)
+	winService.registered <- nil
+
+	<-winService.stopped
+
+	return 0
+}
+
+// serviceHandler is the control handler (HandlerEx), called by the dispatcher. It must return quickly, so the requests are handled asynchronously.
+func serviceHandler(control, _, _, _ uintptr) uintptr {
+	winService.mu.Lock()
+	d := winService.d
+	winService.mu.Unlock()
+
+	// no control but interrogate is accepted until the daemon is started.
+	if d == nil && control != serviceControlInterrogate {
+		return errorCallNotImplemented
+	}
+
+	switch control {
+	case serviceControlStop:
+		go d.shutDownWith(ReasonServiceStop, nil)
+	case serviceControlShutdown, serviceControlPreshutdown:
+		go d.shutDownWith(ReasonSystemShutdown, nil)
+	case serviceControlPause, serviceControlContinue:
+		if !d.config.servicePauseContinue {
+			return errorCallNotImplemented
+		}
+		go pauseOrContinueService(d, control)
+	case serviceControlInterrogate:
+		winService.mu.Lock()
+		reportServiceStatus()
+		winService.mu.Unlock()
+	default:
+		return errorCallNotImplemented
+	}
+
+	return 0
+}
+
+// pauseOrContinueService calls `Pause` or `Resume` for the given control, reporting the pending state while the hooks run.
+// The outcome is not reported once the shutdown has started, so it does not overwrite SERVICE_STOP_PENDING.
+func pauseOrContinueService(d *Daemon, control uintptr) {
+	var pending, target, fallback uint32 = servicePausePending, servicePaused, serviceRunning
+	transition := d.Pause
+	if control == serviceControlContinue {
+		pending, target, fallback = serviceContinuePending, serviceRunning, servicePaused
+		transition = d.Resume
+	}
+
+	if !setServiceStateWhileRunning(d, pending, serviceAccepted(d)) {
+		return
+	}
+
+	state := target
+	if err := transition(d.ctx); err != nil {
+		d.config.logger.ErrorContext(d.ctx, "failed to pause or continue the service", slog.String("error", err.Error()))
+		state = fallback
+	}
+
+	setServiceStateWhileRunning(d, state, serviceAccepted(d))
+}
+
+// serviceAccepted returns the controls accepted by the service while it runs.
+func serviceAccepted(d *Daemon) uint32 {
+	if d.config.servicePauseContinue {
+		return serviceAcceptedWhileRunning | serviceAcceptPauseContinue
+	}
+
+	return serviceAcceptedWhileRunning
+}
+
+// serviceObserver reports SERVICE_STOP_PENDING, with a checkpoint that advances periodically, for as long as the shutdown runs.
+func serviceObserver() observer {
+	var (
+		stop    = make(chan struct{})
+		pending sync.WaitGroup
+	)
+
+	return observer{
+		shutdownStarted: func(context.Context, Reason) {
+			setServiceState(serviceStopPending, 0)
+
+			pending.Go(func() {
+				t := time.NewTicker(serviceCheckpointInterval)
+				defer t.Stop()
+
+				for {
+					select {
+					case <-t.C:
+						winService.mu.Lock()
+						winService.status.CheckPoint++
+						reportServiceStatus()
+						winService.mu.Unlock()
+					case <-stop:
+						return
+					}
+				}
+			})
+		},
+		shutdownFinished: func(context.Context, time.Duration) {
+			close(stop)
+			pending.Wait()
+		},
+	}
+}
+
+// setServiceState reports the given state to the service control manager. The checkpoint is reset and, for pending states, the wait hint is set.
+func setServiceState(state, accepted uint32) {
+	winService.mu.Lock()
+	defer winService.mu.Unlock()
+
+	updateServiceState(state, accepted)
+}
+
+// setServiceStateWhileRunning is like `setServiceState`, but reports nothing and returns false once the shutdown of d has started.
+// The shutdown is checked while holding `winService.mu`, so the SERVICE_STOP_PENDING reported when it starts always comes after.
+func setServiceStateWhileRunning(d *Daemon, state, accepted uint32) bool {
+	winService.mu.Lock()
+	defer winService.mu.Unlock()
+
+	select {
+	case <-d.stopping:
+		return false
+	default:
+	}
+
+	updateServiceState(state, accepted)
+
+	return true
+}
+
+// updateServiceState sets and reports the given state. It should be called while holding `winService.mu`.
+func updateServiceState(state, accepted uint32) {
+	winService.status.CurrentState = state
+	winService.status.ControlsAccepted = accepted
+	winService.status.CheckPoint = 0
+	winService.status.WaitHint = 0
+	if state == serviceStartPending || state == serviceStopPending || state == servicePausePending || state == serviceContinuePending {
+		winService.status.WaitHint = uint32(serviceWaitHint.Milliseconds())
+	}
+
+	reportServiceStatus()
+}
+
+// reportServiceStatus sends the current status to the service control manager. It should be called while holding `winService.mu`.
+func reportServiceStatus() {
+	if winService.handle == 0 {
+		return
+	}
+
+	status := winService.status
+	_, _, _ = procSetServiceStatus.Call(winService.handle, uintptr(unsafe.Pointer(&status)))
+}
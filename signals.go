@@ -0,0 +1,116 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"os"
+	"slices"
+)
+
+// AddSignals adds OS signals to the ones used as stop condition, while the daemon is running (e.g. for plugins loaded at runtime),
+// instead of installing competing handlers. It is a no-op if the daemon is notified for every signal (`WithSignalsNotify()` without signals).
+func (o *Daemon) AddSignals(sig ...os.Signal) {
+	o.signalsMutex.Lock()
+	defer o.signalsMutex.Unlock()
+
+	if o.everySignal() {
+		return
+	}
+
+	for _, s := range sig {
+		if !slices.Contains(o.signals, s) {
+			o.signals = append(o.signals, s)
+		}
+	}
+
+	o.resubscribe()
+}
+
+// RemoveSignals removes OS signals from the ones used as stop condition, while the daemon is running.
+// Removing every signal stops the signal notifications altogether, until signals are added again.
+// It is a no-op if the daemon is notified for every signal (`WithSignalsNotify()` without signals).
+func (o *Daemon) RemoveSignals(sig ...os.Signal) {
+	o.signalsMutex.Lock()
+	defer o.signalsMutex.Unlock()
+
+	if o.everySignal() {
+		return
+	}
+
+	o.signals = slices.DeleteFunc(o.signals, func(s os.Signal) bool { return slices.Contains(sig, s) })
+
+	o.resubscribe()
+}
+
+// everySignal reports whether the daemon is notified for every signal. It should be called while holding `signalsMutex`.
+func (o *Daemon) everySignal() bool {
+	return len(o.signals) == 0 && !o.signalsStopped
+}
+
+// resubscribe replaces the signal notification registration with the current signals. It should be called while holding `signalsMutex`.
+func (o *Daemon) resubscribe() {
+	select {
+	case <-o.stopping:
+		// the registration is stopped at the end of the shutdown.
+		return
+	default:
+	}
+
+	notify := o.config.withPolicySignals(o.signals)
+	for s := range o.onSignal {
+		if !slices.Contains(notify, s) {
+			notify = append(slices.Clone(notify), s)
+		}
+	}
+	if len(notify) == 0 {
+		// an empty list would mean every signal.
+		o.config.stdAPI.SignalStop(o.signalCh)
+	} else {
+		o.config.stdAPI.SignalNotify(o.signalCh, notify...)
+	}
+	o.signalsStopped = len(o.signals) == 0
+
+	o.config.logger.InfoContext(o.ctx, "signals changed", slog.Any("signals", o.signals))
+}
+
+// OnSignal registers fn to be called when sig is received, so signals like SIGHUP or SIGUSR1 can trigger custom behavior (e.g. reload, stats dump)
+// instead of being a stop condition. If sig is also a stop condition, the handler takes precedence. Multiple handlers of the same signal are called in the order they are registered.
+// Handlers are called from the daemon's signal loop with the daemon's context, so a long running handler should start its own goroutine.
+func (o *Daemon) OnSignal(sig os.Signal, fn func(context.Context)) {
+	o.signalsMutex.Lock()
+	defer o.signalsMutex.Unlock()
+
+	if o.onSignal == nil {
+		o.onSignal = map[os.Signal][]func(context.Context){}
+	}
+	_, subscribed := o.onSignal[sig]
+	o.onSignal[sig] = append(o.onSignal[sig], fn)
+
+	if subscribed || o.everySignal() || slices.Contains(o.config.withPolicySignals(o.signals), sig) {
+		// already notified for sig.
+		return
+	}
+
+	o.resubscribe()
+}
+
+// signalHandler returns the handler of a signal that is not a stop condition: one registered using `OnSignal`, the upgrade signal (see `WithUpgradeSignal`), or SIGPIPE with a policy.
+func (o *Daemon) signalHandler(sig os.Signal) (func(context.Context), bool) {
+	o.signalsMutex.Lock()
+	handlers := slices.Clone(o.onSignal[sig])
+	o.signalsMutex.Unlock()
+
+	if len(handlers) > 0 {
+		return func(ctx context.Context) {
+			for _, h := range handlers {
+				h(ctx)
+			}
+		}, true
+	}
+
+	if o.config.upgradeSignal != nil && sig == o.config.upgradeSignal {
+		return o.upgradeSignalHandler, true
+	}
+
+	return o.config.signalHandler(sig)
+}
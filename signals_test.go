@@ -0,0 +1,108 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAddRemoveSignals(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, []os.Signal{os.Interrupt}).Twice()
+	s.EXPECT().SignalNotify(mock.Anything, []os.Signal{os.Interrupt, os.Kill}).Once()
+	s.EXPECT().SignalStop(mock.Anything).Twice()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithSignalsNotify(os.Interrupt),
+	)
+
+	d.AddSignals(os.Kill, os.Interrupt)
+	assert.Equal(t, []os.Signal{os.Interrupt, os.Kill}, d.Signals())
+
+	d.RemoveSignals(os.Kill)
+	assert.Equal(t, []os.Signal{os.Interrupt}, d.Signals())
+
+	// removing every signal stops the notifications.
+	d.RemoveSignals(os.Interrupt)
+	assert.Empty(t, d.Signals())
+
+	d.ShutDown()
+	d.Wait()
+}
+
+func TestAddSignalsEverySignal(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithSignalsNotify(),
+	)
+
+	d.AddSignals(os.Interrupt)
+	d.RemoveSignals(os.Interrupt)
+	assert.Empty(t, d.Signals())
+
+	d.ShutDown()
+	d.Wait()
+}
+
+func TestOnSignal(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, []os.Signal{os.Interrupt}).Once()
+	s.EXPECT().SignalNotify(mock.Anything, []os.Signal{os.Interrupt, os.Kill}).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithSignalsNotify(os.Interrupt),
+	)
+
+	called := make(chan string, 2)
+	d.OnSignal(os.Kill, func(context.Context) { called <- "first" })
+	d.OnSignal(os.Kill, func(context.Context) { called <- "second" })
+
+	d.signalCh <- os.Kill
+	assert.Equal(t, "first", <-called)
+	assert.Equal(t, "second", <-called)
+	assert.Equal(t, StatusRunning, d.State().Status)
+
+	d.ShutDown()
+	d.Wait()
+}
+
+func TestOnSignalStopCondition(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, []os.Signal{os.Interrupt}).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithSignalsNotify(os.Interrupt),
+	)
+
+	called := make(chan struct{})
+	// the handler takes precedence over the stop condition.
+	d.OnSignal(os.Interrupt, func(context.Context) { close(called) })
+
+	d.signalCh <- os.Interrupt
+	<-called
+	assert.Equal(t, StatusRunning, d.State().Status)
+
+	d.ShutDown()
+	d.Wait()
+	assert.Equal(t, ReasonManual, d.ShutdownReason())
+}
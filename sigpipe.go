@@ -0,0 +1,78 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"slices"
+)
+
+// SIGPIPEPolicy defines how the daemon handles SIGPIPE.
+//
+// By default, the go runtime terminates the process when a write to a broken stdout or stderr pipe raises SIGPIPE
+// (e.g. when journald restarts), while it ignores it for every other file descriptor.
+type SIGPIPEPolicy struct {
+	shutdown bool
+	handler  func(context.Context)
+}
+
+var (
+	// SIGPIPEIgnore ignores SIGPIPE, so writes to broken pipes (stdout and stderr included) just return EPIPE.
+	SIGPIPEIgnore = SIGPIPEPolicy{handler: func(context.Context) {}}
+	// SIGPIPEShutdown handles SIGPIPE as a stop condition, like the signals configured using `WithSignalsNotify`.
+	SIGPIPEShutdown = SIGPIPEPolicy{shutdown: true}
+)
+
+// SIGPIPECustom calls f, with the daemon's context, on every SIGPIPE received. f is called from the daemon's signal loop, so it should not block.
+func SIGPIPECustom(f func(context.Context)) SIGPIPEPolicy {
+	return SIGPIPEPolicy{handler: f}
+}
+
+// WithSIGPIPEPolicy installs the given handling of SIGPIPE, instead of leaving it to the go runtime's default behavior.
+// It has no effect on platforms without SIGPIPE.
+func WithSIGPIPEPolicy(p SIGPIPEPolicy) DaemonConfigOption {
+	return func(oc *config) {
+		oc.sigpipePolicy = &p
+	}
+}
+
+// notifySignals returns the signals the daemon should be notified for: the stop condition ones plus the ones that have a policy.
+func (c config) notifySignals() []os.Signal {
+	return c.withPolicySignals(c.signalsNotify)
+}
+
+// withPolicySignals returns the given stop condition signals plus the ones that have a policy or are opted in (see `WithConsoleCtrlEvents` and `WithUpgradeSignal`).
+func (c config) withPolicySignals(stop []os.Signal) []os.Signal {
+	// an empty list means every signal.
+	if len(stop) == 0 {
+		return stop
+	}
+
+	extra := []os.Signal{}
+	if c.sigpipePolicy != nil && sigPIPE != nil {
+		extra = append(extra, sigPIPE)
+	}
+	if c.consoleCtrlEvents && consoleCtrlSignal != nil {
+		extra = append(extra, consoleCtrlSignal)
+	}
+	if c.upgradeSignal != nil {
+		extra = append(extra, c.upgradeSignal)
+	}
+
+	signals := stop
+	for _, s := range extra {
+		if !slices.Contains(signals, s) {
+			signals = append(slices.Clone(signals), s)
+		}
+	}
+
+	return signals
+}
+
+// signalHandler returns the handler of sig, if sig is handled by a policy instead of being a stop condition.
+func (c config) signalHandler(sig os.Signal) (func(context.Context), bool) {
+	if sig != sigPIPE || c.sigpipePolicy == nil || c.sigpipePolicy.shutdown {
+		return nil, false
+	}
+
+	return c.sigpipePolicy.handler, true
+}
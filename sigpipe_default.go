@@ -0,0 +1,8 @@
+//go:build !unix
+
+package daemon
+
+import "os"
+
+// sigPIPE is nil on platforms without SIGPIPE.
+var sigPIPE os.Signal
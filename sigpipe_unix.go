@@ -0,0 +1,10 @@
+//go:build unix
+
+package daemon
+
+import (
+	"os"
+	"syscall"
+)
+
+var sigPIPE os.Signal = syscall.SIGPIPE
@@ -0,0 +1,85 @@
+//go:build unix
+
+package daemon
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSIGPIPEPolicy(t *testing.T) {
+	tests := map[string]struct {
+		policy       SIGPIPEPolicy
+		expectedStop bool
+	}{
+		"ignore":   {policy: SIGPIPEIgnore},
+		"shutdown": {policy: SIGPIPEShutdown, expectedStop: true},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, []os.Signal{os.Interrupt, syscall.SIGPIPE}).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			d := Start(
+				context.Background(),
+				WithLogger(logger(t)),
+				withSTDAPI(s),
+				WithSignalsNotify(os.Interrupt),
+				WithSIGPIPEPolicy(tc.policy),
+			)
+
+			d.signalCh <- syscall.SIGPIPE
+
+			err := d.WaitTimeout(100 * time.Millisecond)
+			if tc.expectedStop {
+				assert.NoError(t, err)
+				assert.Equal(t, ReasonSignal, d.State().Reason)
+			} else {
+				assert.ErrorIs(t, err, context.DeadlineExceeded)
+				d.ShutDown()
+				d.Wait()
+			}
+		})
+	}
+}
+
+func TestSIGPIPECustom(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	called := make(chan struct{})
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithSIGPIPEPolicy(SIGPIPECustom(func(context.Context) { close(called) })),
+	)
+
+	d.signalCh <- syscall.SIGPIPE
+
+	select {
+	case <-called:
+	case <-time.After(time.Second):
+		assert.Fail(t, "custom SIGPIPE handler was not called")
+	}
+
+	assert.Equal(t, StatusRunning, d.State().Status)
+	d.ShutDown()
+	d.Wait()
+}
+
+func TestNotifySignals(t *testing.T) {
+	assert.Equal(t, []os.Signal{os.Interrupt}, config{signalsNotify: []os.Signal{os.Interrupt}}.notifySignals())
+	assert.Equal(t, []os.Signal{os.Interrupt, syscall.SIGPIPE}, config{signalsNotify: []os.Signal{os.Interrupt}, sigpipePolicy: &SIGPIPEIgnore}.notifySignals())
+	assert.Equal(t, []os.Signal{syscall.SIGPIPE}, config{signalsNotify: []os.Signal{syscall.SIGPIPE}, sigpipePolicy: &SIGPIPEIgnore}.notifySignals())
+	assert.Empty(t, config{sigpipePolicy: &SIGPIPEIgnore}.notifySignals())
+}
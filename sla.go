@@ -0,0 +1,106 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SLAMode defines what happens when the graceful shutdown takes longer than the target configured using `WithShutdownSLA`.
+type SLAMode int
+
+const (
+	// SLALog logs an error with the per callback breakdown.
+	SLALog SLAMode = iota
+	// SLAExitCode writes the per callback breakdown to the standard error and makes `WaitExitCode` return a non-zero exit code (4).
+	SLAExitCode
+	// SLAPanic panics with the per callback breakdown, at the very end of the shutdown: once the exit status is written and `Wait` returns.
+	SLAPanic
+)
+
+// WithShutdownSLA sets a target duration for the graceful shutdown, and what happens when it is exceeded.
+// It is meant for development and CI, so drain budget regressions fail loudly (see `SLAExitCode` and `SLAPanic`) instead of a quiet log line.
+// Zero duration (the default) disables it.
+func WithShutdownSLA(d time.Duration, mode SLAMode) DaemonConfigOption {
+	return func(oc *config) {
+		oc.shutdownSLA = d
+		oc.shutdownSLAMode = mode
+	}
+}
+
+type callbackDuration struct {
+	name    string
+	elapsed time.Duration
+}
+
+// slaObserver records the duration of every shutdown callback and enforces the shutdown SLA once the shutdown is done.
+func (o *Daemon) slaObserver() observer {
+	mu := sync.Mutex{}
+	durations := []callbackDuration{}
+
+	return observer{
+		callbackFinished: func(_ context.Context, info CallbackInfo, elapsed time.Duration) {
+			mu.Lock()
+			defer mu.Unlock()
+			durations = append(durations, callbackDuration{name: info.Name, elapsed: elapsed})
+		},
+		shutdownFinished: func(ctx context.Context, elapsed time.Duration) {
+			if elapsed <= o.config.shutdownSLA {
+				return
+			}
+
+			mu.Lock()
+			breakdown := slaBreakdown(o.config.shutdownSLA, elapsed, durations)
+			mu.Unlock()
+
+			switch o.config.shutdownSLAMode {
+			case SLAExitCode:
+				o.mu.Lock()
+				o.slaExceeded = true
+				o.mu.Unlock()
+				_, _ = io.WriteString(o.config.panicReport, breakdown)
+			case SLAPanic:
+				o.mu.Lock()
+				o.slaPanic = breakdown
+				o.mu.Unlock()
+			default:
+				o.config.logger.ErrorContext(ctx, "shutdown SLA exceeded",
+					slog.Duration("sla", o.config.shutdownSLA),
+					slog.Duration("elapsed", elapsed),
+					slog.String("breakdown", breakdown),
+				)
+			}
+		},
+	}
+}
+
+// slaBreakdown describes the shutdown duration per callback, in execution order.
+func slaBreakdown(sla, elapsed time.Duration, durations []callbackDuration) string {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "shutdown SLA exceeded: took %s, target %s\n", elapsed, sla)
+
+	var total time.Duration
+	for _, d := range durations {
+		total += d.elapsed
+		fmt.Fprintf(&b, "  %-40s %10s\n", d.name, d.elapsed)
+	}
+	// the leadership resignation, the drain delay and the drain stages.
+	fmt.Fprintf(&b, "  %-40s %10s\n", "(outside of the callbacks)", max(elapsed-total, 0))
+
+	return b.String()
+}
+
+// panicIfSLAExceeded panics with the breakdown recorded by the SLA observer in `SLAPanic` mode, if the SLA was exceeded.
+func (o *Daemon) panicIfSLAExceeded() {
+	o.mu.Lock()
+	breakdown := o.slaPanic
+	o.mu.Unlock()
+
+	if breakdown != "" {
+		panic(breakdown)
+	}
+}
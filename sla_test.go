@@ -0,0 +1,71 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShutdownSLA(t *testing.T) {
+	tests := map[string]struct {
+		sla              time.Duration
+		expectedExitCode int
+	}{
+		"met":      {sla: time.Second, expectedExitCode: 0},
+		"exceeded": {sla: 10 * time.Millisecond, expectedExitCode: defaultSLAExitCode},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			report := &bytes.Buffer{}
+			stateFile := filepath.Join(t.TempDir(), "state.json")
+			d := Start(
+				context.Background(),
+				WithLogger(logger(t)),
+				withSTDAPI(s),
+				WithStateFile(stateFile),
+				WithShutdownSLA(tc.sla, SLAExitCode),
+				func(oc *config) { oc.panicReport = report },
+			)
+
+			m := namedModule{}
+			d.Defer(m.Stop, func(context.Context) { time.Sleep(20 * time.Millisecond) })
+
+			d.ShutDown()
+			assert.Equal(t, tc.expectedExitCode, d.WaitExitCode())
+
+			c, err := readStateFile(stateFile)
+			require.NoError(t, err)
+			require.Len(t, c.Runs, 1)
+			assert.Equal(t, tc.expectedExitCode, c.Runs[0].ExitCode)
+
+			if tc.expectedExitCode == 0 {
+				assert.Empty(t, report.String())
+			} else {
+				assert.Contains(t, report.String(), "shutdown SLA exceeded")
+				assert.Contains(t, report.String(), "daemon.namedModule.Stop")
+			}
+		})
+	}
+}
+
+func TestShutdownSLAPanic(t *testing.T) {
+	d := &Daemon{config: config{shutdownSLA: time.Millisecond, shutdownSLAMode: SLAPanic}}
+	ob := d.slaObserver()
+	ob.callbackFinished(context.Background(), CallbackInfo{Name: "slow"}, 5*time.Millisecond)
+
+	// the observer only records the breakdown, the panic happens at the very end of the shutdown.
+	assert.NotPanics(t, func() { ob.shutdownFinished(context.Background(), 5*time.Millisecond) })
+
+	assert.PanicsWithValue(t, slaBreakdown(time.Millisecond, 5*time.Millisecond, []callbackDuration{{name: "slow", elapsed: 5 * time.Millisecond}}), d.panicIfSLAExceeded)
+}
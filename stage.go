@@ -0,0 +1,165 @@
+package daemon
+
+import (
+	"context"
+	"log/slog"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+// Stage is a stage of the multi-stage drain that runs at the beginning of the shutdown, before the shutdown callbacks registered using `Defer`.
+type Stage int
+
+const (
+	// StageStopIntake is the first stage, where modules stop accepting new work (e.g. stop consuming, stop accepting connections).
+	StageStopIntake Stage = iota
+	// StageFlush is the second stage, where modules finish or persist the in-flight work (e.g. flush buffers, commit offsets).
+	StageFlush
+	// StageClose is the last stage, where modules release their resources (e.g. close connections and files).
+	StageClose
+
+	stageCount = iota
+
+	// StageCallbacks is not a drain stage: it is the phase of the shutdown callbacks registered using `Defer`, which run after the drain stages.
+	StageCallbacks Stage = stageCount
+)
+
+func (s Stage) String() string {
+	switch s {
+	case StageStopIntake:
+		return "stop_intake"
+	case StageFlush:
+		return "flush"
+	case StageClose:
+		return "close"
+	case StageCallbacks:
+		return "callbacks"
+	default:
+		return "unknown"
+	}
+}
+
+// WithStageBudget sets the budget of the given drain stage. The hooks of the stage receive a context that is done once the budget is exhausted,
+// or once the shutdown grace period is exceeded, whichever comes first. Zero (the default) means the stage is bounded only by the grace period.
+func WithStageBudget(s Stage, d time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		if s >= 0 && s < stageCount {
+			oc.stageBudgets[s] = d
+		}
+	}
+}
+
+// stageHook is a function registered to a drain stage.
+type stageHook struct {
+	name string
+	fn   func(context.Context, CallbackInfo) error
+}
+
+// OnStage appends functions to be called in the given drain stage.
+// The stages run strictly in order (stop intake, flush, close) and the functions of each stage run concurrently.
+// Errors are logged and do not stop the shutdown.
+func (o *Daemon) OnStage(s Stage, f ...func(context.Context) error) {
+	hooks := make([]stageHook, 0, len(f))
+	for _, fn := range f {
+		hooks = append(hooks, stageHook{
+			name: funcName(fn),
+			fn:   func(ctx context.Context, _ CallbackInfo) error { return fn(ctx) },
+		})
+	}
+
+	o.onStage(s, hooks)
+}
+
+// OnStageWithInfo is like `OnStage`, but the functions also receive the `CallbackInfo` of the hook, with the stage as its phase.
+func (o *Daemon) OnStageWithInfo(s Stage, f ...func(context.Context, CallbackInfo) error) {
+	hooks := make([]stageHook, 0, len(f))
+	for _, fn := range f {
+		hooks = append(hooks, stageHook{name: funcName(fn), fn: fn})
+	}
+
+	o.onStage(s, hooks)
+}
+
+func (o *Daemon) onStage(s Stage, hooks []stageHook) {
+	if s < 0 || s >= stageCount {
+		return
+	}
+
+	o.stagesMutex.Lock()
+	defer o.stagesMutex.Unlock()
+	o.stages[s] = append(o.stages[s], hooks...)
+}
+
+// stageNames returns the names of the hooks of every stage that has any.
+func (o *Daemon) stageNames() map[string][]string {
+	o.stagesMutex.Lock()
+	defer o.stagesMutex.Unlock()
+
+	names := map[string][]string{}
+	for s, hooks := range o.stages {
+		for _, h := range hooks {
+			names[Stage(s).String()] = append(names[Stage(s).String()], h.name)
+		}
+	}
+
+	return names
+}
+
+// runStages runs every drain stage in order, until the ctx is done.
+func (o *Daemon) runStages(ctx context.Context, reason Reason) {
+	for s := range Stage(stageCount) {
+		if ctx.Err() != nil {
+			return
+		}
+
+		o.stagesMutex.Lock()
+		hooks := o.stages[s]
+		o.stagesMutex.Unlock()
+
+		if len(hooks) == 0 {
+			continue
+		}
+
+		trace.WithRegion(ctx, "stage_"+s.String(), func() { o.runStage(ctx, s, hooks, reason) })
+	}
+}
+
+// runStage runs the functions of a single stage concurrently, within the stage's budget.
+func (o *Daemon) runStage(ctx context.Context, s Stage, hooks []stageHook, reason Reason) {
+	if budget := o.config.stageBudgets[s]; budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, budget)
+		defer cancel()
+	}
+
+	deadline, _ := ctx.Deadline()
+
+	start := time.Now()
+	wg := sync.WaitGroup{}
+	for i, h := range hooks {
+		info := CallbackInfo{
+			Name:     h.name,
+			Position: i,
+			Total:    len(hooks),
+			Deadline: deadline,
+			Reason:   reason,
+			Phase:    s,
+		}
+
+		wg.Go(func() {
+			defer o.recoverCallback(h.name)
+
+			if err := h.fn(ctx, info); err != nil {
+				o.config.logger.ErrorContext(o.ctx, "drain stage hook failed",
+					slog.String("stage", s.String()),
+					slog.String("callback", h.name),
+					slog.String("error", err.Error()),
+				)
+			}
+		})
+	}
+	wg.Wait()
+
+	o.config.logger.InfoContext(o.ctx, "drain stage done", slog.String("stage", s.String()), slog.Duration("duration", time.Since(start)))
+}
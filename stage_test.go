@@ -0,0 +1,64 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestStages(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithStageBudget(StageFlush, 50*time.Millisecond),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	mu := sync.Mutex{}
+	order := []string{}
+	record := func(name string) error {
+		mu.Lock()
+		defer mu.Unlock()
+		order = append(order, name)
+		return nil
+	}
+
+	// both intake hooks have to run concurrently to be able to release each other.
+	release := make(chan struct{})
+	d.OnStage(StageStopIntake, func(context.Context) error {
+		<-release
+		return record("intake")
+	}, func(context.Context) error {
+		close(release)
+		return record("intake")
+	})
+	d.OnStage(StageClose, func(context.Context) error { return record("close") })
+	d.OnStage(StageFlush, func(ctx context.Context) error {
+		<-ctx.Done() // the stage budget.
+		_ = record("flush")
+		return errors.New("flush timed out")
+	})
+	d.Defer(func(context.Context) { _ = record("callback") })
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.Equal(t, []string{"intake", "intake", "flush", "close", "callback"}, order)
+}
+
+func TestStageString(t *testing.T) {
+	assert.Equal(t, "stop_intake", StageStopIntake.String())
+	assert.Equal(t, "flush", StageFlush.String())
+	assert.Equal(t, "close", StageClose.String())
+	assert.Equal(t, "callbacks", StageCallbacks.String())
+	assert.Equal(t, "unknown", Stage(42).String())
+}
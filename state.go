@@ -0,0 +1,67 @@
+package daemon
+
+import "time"
+
+// Status describes the lifecycle status of the daemon.
+type Status string
+
+const (
+	// StatusRunning is the status of the daemon until a stop condition is met.
+	StatusRunning Status = "running"
+	// StatusPaused is the status of the daemon while it is paused using `Pause`.
+	StatusPaused Status = "paused"
+	// StatusShuttingDown is the status of the daemon while the graceful shutdown is in progress.
+	StatusShuttingDown Status = "shutting_down"
+	// StatusStopped is the status of the daemon after the graceful shutdown is done.
+	StatusStopped Status = "stopped"
+)
+
+// State is a point in time snapshot of the daemon.
+type State struct {
+	InstanceID        string    `json:"instance_id"`
+	Status            Status    `json:"status"`
+	StartedAt         time.Time `json:"started_at"`
+	Reason            Reason    `json:"reason,omitempty"`
+	Cause             string    `json:"cause,omitempty"`
+	ScheduledShutdown time.Time `json:"scheduled_shutdown,omitzero"`
+	CrashLoop         bool      `json:"crash_loop,omitempty"`
+}
+
+// State returns a snapshot of the daemon's current state.
+func (o *Daemon) State() State {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	cause := ""
+	if o.cause != nil {
+		cause = o.cause.Error()
+	}
+
+	return State{
+		InstanceID:        o.instanceID,
+		Status:            o.status,
+		StartedAt:         o.startedAt,
+		Reason:            o.reason,
+		Cause:             cause,
+		ScheduledShutdown: o.scheduledShutdown,
+		CrashLoop:         o.crashLoop,
+	}
+}
+
+// ShutdownCause returns the error that caused the shutdown, if any: the fatal error received, or the cause of the parent
+// (or a watched) context. It returns nil while the daemon is running.
+func (o *Daemon) ShutdownCause() error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.cause
+}
+
+// ShutdownReason returns the stop condition that initiated the shutdown (e.g. `ReasonSignal` or `ReasonFatalError`), so it can be logged and alerted on differently.
+// It returns an empty reason while the daemon is running. The shutdown callbacks can get it, along with the signal and the cause, using `ReasonFromContext`.
+func (o *Daemon) ShutdownReason() Reason {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	return o.reason
+}
@@ -0,0 +1,175 @@
+package daemon
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Run describes a single run (process incarnation) of the daemon, as recorded in the state file.
+type Run struct {
+	InstanceID    string    `json:"instance_id,omitempty"`
+	PID           int       `json:"pid"`
+	StartedAt     time.Time `json:"started_at"`
+	StoppedAt     time.Time `json:"stopped_at,omitzero"`
+	Reason        Reason    `json:"reason,omitempty"`
+	ExitCode      int       `json:"exit_code"`
+	Forced        bool      `json:"forced,omitempty"`
+	GraceExceeded bool      `json:"grace_exceeded,omitempty"`
+}
+
+// Clean reports whether the run completed its graceful shutdown in time.
+// A run that never recorded its end (e.g. it got killed) is not clean.
+func (r Run) Clean() bool {
+	return !r.StoppedAt.IsZero() && !r.Forced && !r.GraceExceeded
+}
+
+type stateFileContent struct {
+	Runs []Run `json:"runs"`
+}
+
+// WithStateFile sets a file where the daemon records its runs: each run is recorded at `Start` and updated with its shutdown reason
+// and exit code once the shutdown is done (or right before an immediate termination).
+// The run before the current one is exposed by `PreviousRun()`.
+func WithStateFile(path string) DaemonConfigOption {
+	return func(oc *config) {
+		oc.stateFile = path
+	}
+}
+
+// PreviousRun returns the run recorded in the state file before the current one.
+// It returns false if there is no state file configured or no previous run is recorded.
+func (o *Daemon) PreviousRun() (Run, bool) {
+	o.stateFileMutex.Lock()
+	defer o.stateFileMutex.Unlock()
+
+	if len(o.runs) < 2 {
+		return Run{}, false
+	}
+
+	return o.runs[len(o.runs)-2], true
+}
+
+// recordRunStart reads the state file, logs the previous run and records the current one.
+func (o *Daemon) recordRunStart() {
+	if o.config.stateFile == "" {
+		return
+	}
+
+	o.stateFileMutex.Lock()
+	defer o.stateFileMutex.Unlock()
+
+	c, err := readStateFile(o.config.stateFile)
+	if err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to read state file", slog.String("error", err.Error()))
+	}
+
+	if len(c.Runs) > 0 {
+		prev := c.Runs[len(c.Runs)-1]
+		attrs := []any{
+			slog.String("previous_instance_id", prev.InstanceID),
+			slog.Time("started_at", prev.StartedAt),
+			slog.Time("stopped_at", prev.StoppedAt),
+			slog.String("reason", string(prev.Reason)),
+			slog.Int("exit_code", prev.ExitCode),
+			slog.Bool("forced", prev.Forced),
+			slog.Bool("grace_exceeded", prev.GraceExceeded),
+		}
+		if prev.Clean() {
+			o.config.logger.InfoContext(o.ctx, "previous run", attrs...)
+		} else {
+			o.config.logger.WarnContext(o.ctx, "previous run did not shut down cleanly", attrs...)
+		}
+	}
+
+	c.Runs = append(c.Runs, Run{InstanceID: o.instanceID, PID: os.Getpid(), StartedAt: o.startedAt})
+	if len(c.Runs) > defaultStateFileHistory {
+		c.Runs = c.Runs[len(c.Runs)-defaultStateFileHistory:]
+	}
+	o.runs = c.Runs
+
+	if err := writeStateFile(o.config.stateFile, c); err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to write state file", slog.String("error", err.Error()))
+	}
+}
+
+// recordRunEnd updates the current run in the state file with the way the daemon stopped.
+func (o *Daemon) recordRunEnd() {
+	if o.config.stateFile == "" {
+		return
+	}
+
+	code := o.exitCode()
+
+	o.stateFileMutex.Lock()
+	defer o.stateFileMutex.Unlock()
+
+	if len(o.runs) == 0 {
+		return
+	}
+
+	o.mu.Lock()
+	cur := &o.runs[len(o.runs)-1]
+	cur.StoppedAt = time.Now()
+	cur.Reason = o.reason
+	cur.ExitCode = code
+	cur.Forced = o.forced
+	cur.GraceExceeded = o.graceExceeded
+	o.mu.Unlock()
+
+	if err := writeStateFile(o.config.stateFile, stateFileContent{Runs: o.runs}); err != nil {
+		o.config.logger.ErrorContext(o.parentCTX, "failed to write state file", slog.String("error", err.Error()))
+	}
+}
+
+func readStateFile(path string) (stateFileContent, error) {
+	c := stateFileContent{}
+
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, err
+	}
+
+	return c, json.Unmarshal(b, &c)
+}
+
+// writeStateFile writes the state file atomically, by writing a temporary file and renaming it.
+func writeStateFile(path string, c stateFileContent) error {
+	b, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, b)
+}
+
+// writeFileAtomic writes b to a temporary file in the same directory and renames it to path.
+func writeFileAtomic(path string, b []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	_, err = f.Write(b)
+	if err == nil {
+		err = f.Sync()
+	}
+	if cErr := f.Close(); err == nil {
+		err = cErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(f.Name())
+	}
+
+	return err
+}
@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+
+	run := func(t *testing.T, stop func(d *Daemon)) *Daemon {
+		t.Helper()
+
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		// we specifically want a context that will not get cancelled at the end of the test
+		d := Start(context.Background(), WithStateFile(path), WithLogger(logger(t)), withSTDAPI(s))
+		stop(d)
+		d.Wait()
+
+		return d
+	}
+
+	first := run(t, func(d *Daemon) {
+		_, found := d.PreviousRun()
+		assert.False(t, found)
+		d.FatalErrorsChannel() <- errors.New("error")
+	})
+
+	second := run(t, func(d *Daemon) {
+		prev, found := d.PreviousRun()
+		require.True(t, found)
+		assert.Equal(t, first.startedAt.UTC(), prev.StartedAt.UTC())
+		assert.Equal(t, ReasonFatalError, prev.Reason)
+		assert.Equal(t, 1, prev.ExitCode)
+		assert.True(t, prev.Clean())
+		d.ShutDown()
+	})
+
+	c, err := readStateFile(path)
+	require.NoError(t, err)
+	require.Len(t, c.Runs, 2)
+	assert.Equal(t, second.startedAt.UTC(), c.Runs[1].StartedAt.UTC())
+	assert.Equal(t, ReasonManual, c.Runs[1].Reason)
+	assert.Equal(t, 0, c.Runs[1].ExitCode)
+}
+
+func TestRunClean(t *testing.T) {
+	now := time.Now()
+	assert.True(t, Run{StartedAt: now, StoppedAt: now}.Clean())
+	assert.False(t, Run{StartedAt: now}.Clean())
+	assert.False(t, Run{StartedAt: now, StoppedAt: now, Forced: true}.Clean())
+	assert.False(t, Run{StartedAt: now, StoppedAt: now, GraceExceeded: true}.Clean())
+}
@@ -0,0 +1,99 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// StatusReport is the JSON snapshot served by the handler returned from `StatusHandler`.
+type StatusReport struct {
+	State
+
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	// Callbacks are the names of the shutdown callbacks, in execution order.
+	Callbacks []string `json:"callbacks"`
+	// Stages are the names of the drain stage hooks, per stage (see `OnStage`).
+	Stages      map[string][]string `json:"stages,omitempty"`
+	LastSignal  string              `json:"last_signal,omitempty"`
+	FatalErrors int64               `json:"fatal_errors"`
+	// Shutdown is the progress of the graceful shutdown, present once it has started.
+	Shutdown *ShutdownProgress `json:"shutdown,omitempty"`
+}
+
+// ShutdownProgress describes the progress of the graceful shutdown.
+type ShutdownProgress struct {
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	// Done are the names of the shutdown callbacks that have already run.
+	Done []string `json:"done"`
+	// Running is the name of the shutdown callback that is running, empty while the drain stages run.
+	Running string `json:"running,omitempty"`
+	// Remaining are the names of the shutdown callbacks that have not started yet.
+	Remaining []string `json:"remaining"`
+}
+
+// StatusHandler returns an http.Handler that serves a JSON `StatusReport` of the daemon, to be mounted on an existing (internal) mux.
+func (o *Daemon) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(o.statusReport())
+	})
+}
+
+// ReadyzHandler returns an http.Handler for readiness probes (e.g. kubernetes), to be mounted on an existing mux.
+// It responds with 200 while the daemon is running and with 503 once the shutdown (including the drain delay) has started, while the daemon is paused,
+// or if it is crash looping (see `WithCrashLoopDetection`).
+func (o *Daemon) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		state := o.State()
+		if state.Status != StatusRunning {
+			http.Error(w, string(state.Status), http.StatusServiceUnavailable)
+			return
+		}
+
+		if state.CrashLoop {
+			http.Error(w, "crash_loop", http.StatusServiceUnavailable)
+			return
+		}
+
+		_, _ = w.Write([]byte("ok\n"))
+	})
+}
+
+// statusReport builds a snapshot of the daemon's status.
+func (o *Daemon) statusReport() StatusReport {
+	r := StatusReport{
+		State:       o.State(),
+		Stages:      o.stageNames(),
+		FatalErrors: o.fatalErrors.Load(),
+	}
+	r.UptimeSeconds = time.Since(r.StartedAt).Seconds()
+
+	o.mu.Lock()
+	if o.lastSignal != nil {
+		r.LastSignal = o.lastSignal.String()
+	}
+	names, shutdownStartedAt := o.shutdownCallbacks, o.shutdownStartedAt
+	o.mu.Unlock()
+
+	if shutdownStartedAt.IsZero() {
+		// the callbacks mutex is only held by the shutdown while the callbacks run.
+		r.Callbacks = o.callbackNames()
+
+		return r
+	}
+
+	r.Callbacks = names
+	r.Shutdown = &ShutdownProgress{ElapsedSeconds: time.Since(shutdownStartedAt).Seconds(), Done: []string{}, Remaining: names}
+	if r.Status == StatusStopped {
+		r.Shutdown.Done, r.Shutdown.Remaining = names, []string{}
+
+		return r
+	}
+
+	if pos := int(o.callbackPosition.Load()); pos >= 0 && pos < len(names) {
+		r.Shutdown.Done, r.Shutdown.Running, r.Shutdown.Remaining = names[:pos], names[pos], names[pos+1:]
+	}
+
+	return r
+}
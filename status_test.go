@@ -0,0 +1,116 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatusHandler(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	report := func() StatusReport {
+		rec := httptest.NewRecorder()
+		d.StatusHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/status", nil))
+		assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+		r := StatusReport{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &r))
+
+		return r
+	}
+
+	m := namedModule{}
+	d.Defer(m.Stop)
+	d.OnStage(StageFlush, func(context.Context) error { return nil })
+
+	r := report()
+	assert.Equal(t, StatusRunning, r.Status)
+	assert.Equal(t, []string{"daemon.namedModule.Stop"}, r.Callbacks)
+	assert.Len(t, r.Stages["flush"], 1)
+	assert.Nil(t, r.Shutdown)
+
+	running := make(chan struct{})
+	release := make(chan struct{})
+	d.Defer(func(context.Context) {
+		close(running)
+		<-release
+	})
+
+	d.signalCh <- os.Interrupt
+	<-running
+	// the fatal errors keep being received during the shutdown.
+	d.FatalErrorsChannel() <- errors.New("error")
+	assert.Eventually(t, func() bool { return report().FatalErrors == 1 }, time.Second, 10*time.Millisecond)
+
+	r = report()
+	assert.Equal(t, StatusShuttingDown, r.Status)
+	assert.Equal(t, os.Interrupt.String(), r.LastSignal)
+	require.NotNil(t, r.Shutdown)
+	assert.Empty(t, r.Shutdown.Done)
+	assert.NotEmpty(t, r.Shutdown.Running)
+	assert.Equal(t, []string{"daemon.namedModule.Stop"}, r.Shutdown.Remaining)
+
+	close(release)
+	d.Wait()
+
+	r = report()
+	assert.Equal(t, StatusStopped, r.Status)
+	assert.Len(t, r.Shutdown.Done, 2)
+	assert.Empty(t, r.Shutdown.Remaining)
+}
+
+func TestReadyzHandler(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		WithDrainDelay(10*time.Millisecond),
+	)
+
+	readyz := func() int {
+		rec := httptest.NewRecorder()
+		d.ReadyzHandler().ServeHTTP(rec, httptest.NewRequest("GET", "/readyz", nil))
+
+		return rec.Code
+	}
+
+	assert.Equal(t, 200, readyz())
+
+	require.NoError(t, d.Pause(t.Context()))
+	assert.Equal(t, 503, readyz())
+	require.NoError(t, d.Resume(t.Context()))
+	assert.Equal(t, 200, readyz())
+
+	drainingCode := 0
+	d.OnResignLeadership(func(context.Context) error {
+		// before the drain delay.
+		drainingCode = readyz()
+		return nil
+	})
+
+	d.ShutDown()
+	d.Wait()
+	assert.Equal(t, 503, drainingCode)
+	assert.Equal(t, 503, readyz())
+}
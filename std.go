@@ -3,18 +3,184 @@ package daemon
 import (
 	"os"
 	"os/signal"
+	"slices"
+	"sync"
 )
 
 type std struct{}
 
 func (std) SignalStop(c chan<- os.Signal) {
-	signal.Stop(c)
+	broker.unsubscribe(c)
 }
 
 func (std) SignalNotify(c chan<- os.Signal, sig ...os.Signal) {
-	signal.Notify(c, sig...)
+	broker.subscribe(c, sig...)
 }
 
 func (std) OSExit(code int) {
-	os.Exit(code)
+	broker.exit(code)
+}
+
+// broker is the process wide signal broker used by every daemon.
+var broker = newSignalBroker()
+
+// signalBroker allows multiple daemons to coexist in the same process.
+// It owns a single channel registered with `signal.Notify` for the union of the signals every daemon is interested in,
+// and fans out each received signal to the daemons' channels. It also ensures that only one daemon terminates the process.
+type signalBroker struct {
+	mu   sync.Mutex
+	subs map[chan<- os.Signal][]os.Signal
+	in   chan os.Signal
+	// quit stops the fan out of in, which runs while there are subscribers.
+	quit chan struct{}
+
+	// the signals currently registered on in, or every signal if notifiedAll.
+	notified    []os.Signal
+	notifiedAll bool
+
+	exitOnce sync.Once
+}
+
+func newSignalBroker() *signalBroker {
+	return &signalBroker{
+		subs: map[chan<- os.Signal][]os.Signal{},
+		in:   make(chan os.Signal, 1),
+	}
+}
+
+// subscribe registers c to receive the given signals (or every signal if none is given, like `signal.Notify`).
+func (b *signalBroker) subscribe(c chan<- os.Signal, sig ...os.Signal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.quit == nil {
+		b.quit = make(chan struct{})
+		go b.fanOut(b.quit)
+	}
+
+	b.subs[c] = sig
+	b.rewire()
+}
+
+// unsubscribe stops relaying signals to c.
+func (b *signalBroker) unsubscribe(c chan<- os.Signal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if _, found := b.subs[c]; !found {
+		return
+	}
+
+	delete(b.subs, c)
+	b.rewire()
+
+	if len(b.subs) == 0 {
+		close(b.quit)
+		b.quit = nil
+	}
+}
+
+// rewire updates the registration of the internal channel to the union of the subscribed signals. It should be called while holding `mu`.
+// Added signals are registered on top of the existing registration, so the signals that stay subscribed are neither delivered twice
+// nor fall back to their default behavior. Since `signal.Stop` is the only way to unregister a channel without affecting the
+// registrations made outside of the broker, removing signals replaces the registration of the internal channel.
+func (b *signalBroker) rewire() {
+	if len(b.subs) == 0 {
+		signal.Stop(b.in)
+		b.notified, b.notifiedAll = nil, false
+
+		// drop a signal left undelivered, so it is not relayed to the next subscribers.
+		select {
+		case <-b.in:
+		default:
+		}
+
+		return
+	}
+
+	u := b.union()
+	switch {
+	case u == nil:
+		if !b.notifiedAll {
+			signal.Notify(b.in)
+		}
+		b.notified, b.notifiedAll = nil, true
+
+	case b.notifiedAll || len(difference(b.notified, u)) > 0:
+		signal.Stop(b.in)
+		signal.Notify(b.in, u...)
+		b.notified, b.notifiedAll = u, false
+
+	default:
+		if added := difference(u, b.notified); len(added) > 0 {
+			signal.Notify(b.in, added...)
+		}
+		b.notified = u
+	}
+}
+
+// difference returns the signals of a that are not in b.
+func difference(a, b []os.Signal) []os.Signal {
+	d := []os.Signal{}
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			d = append(d, s)
+		}
+	}
+
+	return d
+}
+
+// union returns the union of the subscribed signals, or nil if any of the subscribers is interested in every signal.
+func (b *signalBroker) union() []os.Signal {
+	u := []os.Signal{}
+	for _, sigs := range b.subs {
+		if len(sigs) == 0 {
+			return nil
+		}
+		for _, s := range sigs {
+			if !slices.Contains(u, s) {
+				u = append(u, s)
+			}
+		}
+	}
+
+	return u
+}
+
+// fanOut relays the received signals until quit is closed.
+func (b *signalBroker) fanOut(quit <-chan struct{}) {
+	for {
+		select {
+		case s := <-b.in:
+			b.dispatch(s)
+		case <-quit:
+			return
+		}
+	}
+}
+
+// dispatch relays the signal to every subscriber that is interested in it.
+// Like `signal.Notify`, it does not block on a subscriber that is not ready to receive.
+func (b *signalBroker) dispatch(s os.Signal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for c, sigs := range b.subs {
+		if len(sigs) > 0 && !slices.Contains(sigs, s) {
+			continue
+		}
+
+		select {
+		case c <- s:
+		default:
+		}
+	}
+}
+
+// exit terminates the process once, no matter how many daemons request it.
+func (b *signalBroker) exit(code int) {
+	b.exitOnce.Do(func() {
+		os.Exit(code)
+	})
 }
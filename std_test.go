@@ -0,0 +1,64 @@
+package daemon
+
+import (
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignalBroker(t *testing.T) {
+	b := newSignalBroker()
+
+	a := make(chan os.Signal, 2)
+	c := make(chan os.Signal, 2)
+	all := make(chan os.Signal, 2)
+
+	b.subscribe(a, os.Interrupt)
+	b.subscribe(c, os.Interrupt, syscall.SIGTERM)
+	assert.ElementsMatch(t, []os.Signal{os.Interrupt, syscall.SIGTERM}, b.notified)
+	b.subscribe(all)
+	assert.Nil(t, b.union())
+	assert.True(t, b.notifiedAll)
+
+	b.dispatch(os.Interrupt)
+	b.dispatch(syscall.SIGTERM)
+
+	assert.Equal(t, []os.Signal{os.Interrupt}, drain(a))
+	assert.Equal(t, []os.Signal{os.Interrupt, syscall.SIGTERM}, drain(c))
+	assert.Equal(t, []os.Signal{os.Interrupt, syscall.SIGTERM}, drain(all))
+
+	b.unsubscribe(all)
+	assert.ElementsMatch(t, []os.Signal{os.Interrupt, syscall.SIGTERM}, b.union())
+	assert.ElementsMatch(t, []os.Signal{os.Interrupt, syscall.SIGTERM}, b.notified)
+	assert.False(t, b.notifiedAll)
+
+	// a full subscriber channel does not block the rest.
+	b.dispatch(os.Interrupt)
+	b.dispatch(os.Interrupt)
+	b.dispatch(os.Interrupt)
+	assert.Len(t, drain(a), 2)
+	assert.Len(t, drain(c), 2)
+
+	b.unsubscribe(c)
+	assert.Equal(t, []os.Signal{os.Interrupt}, b.notified)
+
+	b.unsubscribe(a)
+	b.unsubscribe(a)
+	assert.Empty(t, b.notified)
+	// the fan out stops along with the last subscriber.
+	assert.Nil(t, b.quit)
+}
+
+func drain(c chan os.Signal) []os.Signal {
+	r := []os.Signal{}
+	for {
+		select {
+		case s := <-c:
+			r = append(r, s)
+		default:
+			return r
+		}
+	}
+}
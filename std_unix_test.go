@@ -0,0 +1,84 @@
+//go:build unix
+
+package daemon
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalBrokerDelivery(t *testing.T) {
+	b := newSignalBroker()
+
+	first := make(chan os.Signal, 1)
+	second := make(chan os.Signal, 1)
+	b.subscribe(first, syscall.SIGUSR1)
+	b.subscribe(second, syscall.SIGUSR1)
+	defer b.unsubscribe(first)
+	defer b.unsubscribe(second)
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	for _, c := range []chan os.Signal{first, second} {
+		select {
+		case s := <-c:
+			assert.Equal(t, syscall.SIGUSR1, s)
+		case <-time.After(5 * time.Second):
+			t.Fatal("signal not delivered")
+		}
+	}
+}
+
+func TestSignalBrokerRewire(t *testing.T) {
+	b := newSignalBroker()
+
+	c := make(chan os.Signal, 2)
+	b.subscribe(c, syscall.SIGUSR2)
+	defer b.unsubscribe(c)
+
+	// widening the union keeps the existing registration, so a signal is relayed once.
+	other := make(chan os.Signal, 1)
+	b.subscribe(other, syscall.SIGUSR1)
+	b.unsubscribe(other)
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR2))
+
+	select {
+	case s := <-c:
+		assert.Equal(t, syscall.SIGUSR2, s)
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal not delivered")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Empty(t, drain(c))
+}
+
+func TestSignalBrokerForeignRegistration(t *testing.T) {
+	b := newSignalBroker()
+
+	// a registration made outside of the broker, e.g. by the host application.
+	foreign := make(chan os.Signal, 1)
+	signal.Notify(foreign, syscall.SIGUSR1)
+	defer signal.Stop(foreign)
+
+	c := make(chan os.Signal, 1)
+	b.subscribe(c, syscall.SIGUSR1, syscall.SIGUSR2)
+	b.subscribe(c, syscall.SIGUSR2)
+	b.unsubscribe(c)
+
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGUSR1))
+
+	select {
+	case s := <-foreign:
+		assert.Equal(t, syscall.SIGUSR1, s)
+	case <-time.After(5 * time.Second):
+		t.Fatal("signal not delivered")
+	}
+}
@@ -0,0 +1,46 @@
+package daemon
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// WithSubreaper marks the daemon's process as a child subreaper (PR_SET_CHILD_SUBREAPER) at `Start`, so orphaned descendants
+// (e.g. grandchildren of the commands managed using `Command`) get re-parented to it instead of PID 1, and starts a loop that reaps them once they exit.
+// The reaping loop never reaps the commands managed using `Command`; children started in any other way should not be waited for, since they might be reaped by the loop.
+// It is only supported on linux.
+func WithSubreaper() DaemonConfigOption {
+	return func(oc *config) {
+		oc.subreaper = true
+	}
+}
+
+var (
+	// managedPIDs holds the pids of the commands managed using `Command`, which are reaped by their own `Wait` and not by the reaping loop.
+	managedPIDs sync.Map
+	// reapMutex is held for reading while a command is started and its pid is stored in managedPIDs, and for writing while reaping,
+	// so a command that exits right away is not reaped before its pid is stored.
+	reapMutex sync.RWMutex
+)
+
+// startSubreaper marks the process as a child subreaper and starts the reaping loop, if configured.
+func (o *Daemon) startSubreaper() {
+	if !o.config.subreaper {
+		return
+	}
+
+	if err := setChildSubreaper(); err != nil {
+		o.config.logger.WarnContext(o.ctx, "failed to become a child subreaper", slog.String("error", err.Error()))
+		return
+	}
+
+	o.startReaper()
+}
+
+// startReaper spawns a go routine that periodically reaps the orphaned zombie children of the process.
+func (o *Daemon) startReaper() {
+	go o.poll(defaultReapInterval, func() bool {
+		o.reap()
+		return false
+	})
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package daemon
+
+import "errors"
+
+// setChildSubreaper is not supported outside linux.
+func setChildSubreaper() error {
+	return errors.ErrUnsupported
+}
+
+// reapOrphans is not supported outside linux.
+func reapOrphans(_ func(pid int) bool) ([]int, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,77 @@
+package daemon
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+)
+
+// prSetChildSubreaper is the PR_SET_CHILD_SUBREAPER prctl option.
+const prSetChildSubreaper = 36
+
+func setChildSubreaper() error {
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetChildSubreaper, 1, 0); errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+// reapOrphans reaps every zombie child of the process, except the managed ones, and returns their pids.
+func reapOrphans(managed func(pid int) bool) ([]int, error) {
+	stats, err := filepath.Glob("/proc/[0-9]*/stat")
+	if err != nil {
+		return nil, err
+	}
+
+	self := os.Getpid()
+	reaped := []int{}
+	for _, path := range stats {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			// the process exited meanwhile.
+			continue
+		}
+
+		pid, state, ppid, ok := parseProcStat(b)
+		if !ok || ppid != self || state != 'Z' || managed(pid) {
+			continue
+		}
+
+		ws := syscall.WaitStatus(0)
+		if wpid, err := syscall.Wait4(pid, &ws, syscall.WNOHANG, nil); err == nil && wpid == pid {
+			reaped = append(reaped, pid)
+		}
+	}
+
+	return reaped, nil
+}
+
+// parseProcStat parses the pid, the state and the parent pid out of the content of a `/proc/<pid>/stat` file.
+func parseProcStat(b []byte) (int, byte, int, bool) {
+	// the command name is in parenthesis and can contain spaces and parenthesis, so the fields after it are located using the last ')'.
+	open := bytes.IndexByte(b, '(')
+	end := bytes.LastIndexByte(b, ')')
+	if open < 1 || end < open {
+		return 0, 0, 0, false
+	}
+
+	pid, err := strconv.Atoi(string(bytes.TrimSpace(b[:open])))
+	if err != nil {
+		return 0, 0, 0, false
+	}
+
+	fields := bytes.Fields(b[end+1:])
+	if len(fields) < 2 || len(fields[0]) != 1 {
+		return 0, 0, 0, false
+	}
+
+	ppid, err := strconv.Atoi(string(fields[1]))
+	if err != nil {
+		return 0, 0, 0, false
+	}
+
+	return pid, fields[0][0], ppid, true
+}
@@ -0,0 +1,57 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSubreaper(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithSubreaper(), WithLogger(logger(t)), withSTDAPI(s))
+	defer func() {
+		d.ShutDown()
+		d.Wait()
+	}()
+
+	// the shell exits right away, orphaning its background child.
+	out, err := exec.CommandContext(t.Context(), "sh", "-c", "sleep 0.2 & echo $!").Output()
+	require.NoError(t, err)
+	orphan, err := strconv.Atoi(strings.TrimSpace(string(out)))
+	require.NoError(t, err)
+
+	// the orphan is re-parented to the subreaper.
+	b, err := os.ReadFile("/proc/" + strconv.Itoa(orphan) + "/stat")
+	require.NoError(t, err)
+	_, _, ppid, ok := parseProcStat(b)
+	require.True(t, ok)
+	assert.Equal(t, os.Getpid(), ppid)
+
+	// and reaped once it exits.
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat("/proc/" + strconv.Itoa(orphan))
+		return os.IsNotExist(err)
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestParseProcStat(t *testing.T) {
+	pid, state, ppid, ok := parseProcStat([]byte("1234 (my (weird) cmd) Z 42 1234 1234 0 -1 4194560"))
+	assert.True(t, ok)
+	assert.Equal(t, 1234, pid)
+	assert.Equal(t, byte('Z'), state)
+	assert.Equal(t, 42, ppid)
+
+	_, _, _, ok = parseProcStat([]byte("garbage"))
+	assert.False(t, ok)
+}
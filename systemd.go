@@ -0,0 +1,302 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNoFileDescriptor is returned (wrapped) by `Upgrade`, and logged when storing the listeners in the systemd file descriptor store,
+// when a listener registered using `AdoptListener` can not provide its file descriptor.
+var ErrNoFileDescriptor = errors.New("listener has no file descriptor")
+
+// WithSystemdNotify enables the systemd notify protocol (Type=notify services): `READY=1` is sent once the daemon is started and `STOPPING=1` once the shutdown starts.
+// During the shutdown, the progress of the shutdown callbacks is reported as the service's `STATUS`.
+// It has no effect if the `NOTIFY_SOCKET` environment variable is not set.
+func WithSystemdNotify() DaemonConfigOption {
+	return func(oc *config) {
+		oc.systemdNotify = true
+	}
+}
+
+// WithSystemdFDStore pushes, once the shutdown starts, the listeners created using `Listen` to the systemd file descriptor store (`FDSTORE=1`),
+// so they are passed back to the next start of the service, and the connections pending in their backlog survive a restart.
+// The service needs `FileDescriptorStoreMax=` to be set. It implies `WithSystemdNotify`.
+func WithSystemdFDStore() DaemonConfigOption {
+	return func(oc *config) {
+		oc.systemdNotify = true
+		oc.systemdFDStore = true
+	}
+}
+
+// systemdObserver reports the shutdown progress to systemd, so `systemctl status` shows it, e.g. `STATUS=stopping: 3/7 callbacks done (kafka-consumer)`.
+// While within the grace period, it also extends systemd's stop timeout (`EXTEND_TIMEOUT_USEC`) up to the deadline of the callbacks.
+// Besides, for as long as the shutdown runs, the stop timeout is periodically extended (see `extendSystemdTimeout`).
+func (o *Daemon) systemdObserver() observer {
+	notify := func(ctx context.Context, state string) {
+		if err := sdNotify(state); err != nil {
+			o.config.logger.ErrorContext(ctx, "failed to notify systemd", slog.String("error", err.Error()))
+		}
+	}
+
+	var (
+		stopExtending = make(chan struct{})
+		extending     sync.WaitGroup
+	)
+
+	return observer{
+		shutdownStarted: func(ctx context.Context, _ Reason) {
+			extending.Go(func() { o.extendSystemdTimeout(ctx, stopExtending, notify) })
+		},
+		shutdownFinished: func(context.Context, time.Duration) {
+			close(stopExtending)
+			extending.Wait()
+		},
+		callbackStarted: func(ctx context.Context, info CallbackInfo) {
+			state := fmt.Sprintf("STATUS=stopping: %d/%d callbacks done (%s)", info.Position, info.Total, info.Name)
+			if !info.Deadline.IsZero() {
+				state += fmt.Sprintf("\nEXTEND_TIMEOUT_USEC=%d", (time.Until(info.Deadline) + systemdTimeoutMargin).Microseconds())
+			}
+			notify(ctx, state)
+		},
+		callbackFinished: func(ctx context.Context, info CallbackInfo, _ time.Duration) {
+			if info.Position+1 == info.Total {
+				notify(ctx, fmt.Sprintf("STATUS=stopping: %d/%d callbacks done", info.Total, info.Total))
+			}
+		},
+	}
+}
+
+// extendSystemdTimeout extends systemd's stop timeout every `systemdExtendInterval` until stop is closed, so the unit is not killed
+// while the shutdown is slow but still within its budget: the drain delay and jitter, the grace period, and the flush budget.
+// The timeout is never extended past that budget, unless the grace period is infinite.
+func (o *Daemon) extendSystemdTimeout(ctx context.Context, stop <-chan struct{}, notify func(context.Context, string)) {
+	interval := o.config.systemdExtendInterval
+	if interval <= 0 {
+		return
+	}
+
+	o.mu.Lock()
+	grace := o.shutdownGrace()
+	o.mu.Unlock()
+
+	var budgetEnd time.Time
+	if grace > 0 {
+		budgetEnd = time.Now().Add(o.config.drainDelay + o.config.shutdownJitter + grace + o.config.flushBudget + systemdTimeoutMargin)
+	}
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			extend := 2 * interval
+			if !budgetEnd.IsZero() {
+				extend = min(extend, time.Until(budgetEnd))
+			}
+			if extend > 0 {
+				notify(ctx, fmt.Sprintf("EXTEND_TIMEOUT_USEC=%d", extend.Microseconds()))
+			}
+
+		case <-stop:
+			return
+		}
+	}
+}
+
+// notifySystemdReady sends `READY=1` to systemd, if enabled.
+func (o *Daemon) notifySystemdReady() {
+	if !o.config.systemdNotify {
+		return
+	}
+
+	if err := sdNotify("READY=1"); err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to notify systemd", slog.String("error", err.Error()))
+	}
+}
+
+// notifySystemdReloading sends `RELOADING=1`, along with the current monotonic time, to systemd, if enabled.
+func (o *Daemon) notifySystemdReloading() {
+	if !o.config.systemdNotify {
+		return
+	}
+
+	state := "RELOADING=1"
+	if usec := monotonicUsec(); usec > 0 {
+		state += fmt.Sprintf("\nMONOTONIC_USEC=%d", usec)
+	}
+
+	if err := sdNotify(state); err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to notify systemd", slog.String("error", err.Error()))
+	}
+}
+
+// notifySystemdReloaded sends `READY=1` to systemd once the reload is done, with the reload error as status, if enabled.
+func (o *Daemon) notifySystemdReloaded(reloadErr error) {
+	if !o.config.systemdNotify {
+		return
+	}
+
+	state := "READY=1"
+	if reloadErr != nil {
+		state += "\nSTATUS=reload failed: " + strings.ReplaceAll(reloadErr.Error(), "\n", "; ")
+	}
+
+	if err := sdNotify(state); err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to notify systemd", slog.String("error", err.Error()))
+	}
+}
+
+// notifySystemdStopping sends `STOPPING=1` to systemd and pushes the listeners to its file descriptor store, if enabled.
+func (o *Daemon) notifySystemdStopping() {
+	if !o.config.systemdNotify {
+		return
+	}
+
+	if err := sdNotify("STOPPING=1"); err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to notify systemd", slog.String("error", err.Error()))
+	}
+
+	if o.config.systemdFDStore {
+		o.storeListeners()
+	}
+}
+
+// storeListeners pushes every listener, that is not already there, to the systemd file descriptor store.
+// Adopted listeners are skipped, since systemd keeps the stored file descriptors until they are explicitly removed.
+func (o *Daemon) storeListeners() {
+	o.listenersMutex.Lock()
+	listeners := o.listeners
+	o.listenersMutex.Unlock()
+
+	for _, l := range listeners {
+		if l.adopted {
+			continue
+		}
+
+		if err := storeListener(l); err != nil {
+			o.config.logger.ErrorContext(o.ctx, "failed to store listener", slog.String("name", l.name), slog.String("error", err.Error()))
+		}
+	}
+}
+
+func storeListener(l managedListener) error {
+	f, err := l.file()
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return sdNotifyWithFiles("FDSTORE=1\nFDNAME="+l.name, f)
+}
+
+// file returns a duplicate of the listener's file descriptor.
+func (l managedListener) file() (*os.File, error) {
+	filer, ok := l.ln.(interface{ File() (*os.File, error) })
+	if !ok {
+		return nil, ErrNoFileDescriptor
+	}
+
+	return filer.File()
+}
+
+// sdNotify sends the state to the systemd notify socket. It is a no-op if `NOTIFY_SOCKET` is not set.
+func sdNotify(state string) error {
+	return sdNotifyWithFiles(state)
+}
+
+// sdNotifyWithFiles sends the state, along with the file descriptors of the given files, to the systemd notify socket.
+// It is a no-op if `NOTIFY_SOCKET` is not set.
+func sdNotifyWithFiles(state string, files ...*os.File) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	// net resolves a leading '@' to the abstract namespace.
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if len(files) == 0 {
+		_, err = conn.Write([]byte(state))
+		return err
+	}
+
+	return writeWithFiles(conn, []byte(state), files)
+}
+
+// inherited holds the file descriptors passed to the process by systemd or by the process that started it using `Upgrade`, by name.
+// They are consumed once, by the first `Listen` with the same name. upgrade is the connection to the process that started it using `Upgrade`, if any.
+var inherited = struct {
+	once    sync.Once
+	mu      sync.Mutex
+	files   map[string][]*os.File
+	upgrade *net.UnixConn
+}{}
+
+// loadInherited collects the inherited file descriptors, once per process.
+func loadInherited() {
+	inherited.once.Do(func() {
+		inherited.files, inherited.upgrade = inheritedFiles()
+	})
+}
+
+func takeInheritedFile(name string) *os.File {
+	loadInherited()
+
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+
+	files := inherited.files[name]
+	if len(files) == 0 {
+		return nil
+	}
+
+	inherited.files[name] = files[1:]
+
+	return files[0]
+}
+
+// listenFD is a file descriptor passed by systemd, as described by the `LISTEN_*` environment variables.
+type listenFD struct {
+	fd   int
+	name string
+}
+
+// listenFDsStart is the first file descriptor passed by systemd.
+const listenFDsStart = 3
+
+// parseListenFDs parses the values of the `LISTEN_PID`, `LISTEN_FDS` and `LISTEN_FDNAMES` environment variables.
+// Nothing is returned if the file descriptors are not meant for the process with the given pid.
+func parseListenFDs(pid int, listenPID, listenFDs, listenFDNames string) []listenFD {
+	if listenPID != fmt.Sprint(pid) {
+		return nil
+	}
+
+	n := 0
+	if _, err := fmt.Sscan(listenFDs, &n); err != nil || n <= 0 {
+		return nil
+	}
+
+	names := strings.Split(listenFDNames, ":")
+	fds := make([]listenFD, 0, n)
+	for i := range n {
+		name := "unknown" // systemd's default name.
+		if i < len(names) && names[i] != "" {
+			name = names[i]
+		}
+		fds = append(fds, listenFD{fd: listenFDsStart + i, name: name})
+	}
+
+	return fds
+}
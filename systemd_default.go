@@ -0,0 +1,15 @@
+//go:build !unix
+
+package daemon
+
+import (
+	"errors"
+	"net"
+	"os"
+)
+
+// writeWithFiles returns `errors.ErrUnsupported`, since passing file descriptors is unix only.
+func writeWithFiles(*net.UnixConn, []byte, []*os.File) error { return errors.ErrUnsupported }
+
+// inheritedFiles returns nothing, since systemd is linux only and passing file descriptors is unix only.
+func inheritedFiles() (map[string][]*os.File, *net.UnixConn) { return nil, nil }
@@ -0,0 +1,54 @@
+package daemon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseListenFDs(t *testing.T) {
+	tests := map[string]struct {
+		listenPID, listenFDs, listenFDNames string
+		expected                            []listenFD
+	}{
+		"other process": {listenPID: "2", listenFDs: "1", listenFDNames: "http"},
+		"no fds":        {listenPID: "1", listenFDs: "0"},
+		"invalid fds":   {listenPID: "1", listenFDs: "two"},
+		"named": {
+			listenPID: "1", listenFDs: "2", listenFDNames: "http:grpc",
+			expected: []listenFD{{fd: 3, name: "http"}, {fd: 4, name: "grpc"}},
+		},
+		"unnamed": {
+			listenPID: "1", listenFDs: "2", listenFDNames: "http",
+			expected: []listenFD{{fd: 3, name: "http"}, {fd: 4, name: "unknown"}},
+		},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseListenFDs(1, tc.listenPID, tc.listenFDs, tc.listenFDNames))
+		})
+	}
+}
+
+func TestParseWatchdogUsec(t *testing.T) {
+	tests := map[string]struct {
+		watchdogPID  string
+		watchdogUsec string
+		expected     time.Duration
+	}{
+		"enabled":      {watchdogUsec: "30000000", expected: 30 * time.Second},
+		"matching pid": {watchdogPID: "42", watchdogUsec: "1000", expected: time.Millisecond},
+		"other pid":    {watchdogPID: "43", watchdogUsec: "1000"},
+		"not enabled":  {},
+		"invalid":      {watchdogUsec: "abc"},
+		"zero":         {watchdogUsec: "0"},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, tc.expected, parseWatchdogUsec(42, tc.watchdogPID, tc.watchdogUsec))
+		})
+	}
+}
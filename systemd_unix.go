@@ -0,0 +1,58 @@
+//go:build unix
+
+package daemon
+
+import (
+	"net"
+	"os"
+	"syscall"
+)
+
+// writeWithFiles writes b to the connected datagram socket, passing along the file descriptors of the files.
+func writeWithFiles(conn *net.UnixConn, b []byte, files []*os.File) error {
+	fds := make([]int, 0, len(files))
+	for _, f := range files {
+		fds = append(fds, int(f.Fd()))
+	}
+	oob := syscall.UnixRights(fds...)
+
+	rc, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+
+	// net refuses to write a message with ancillary data to a connected datagram socket, so sendmsg is called directly.
+	var sendErr error
+	err = rc.Write(func(fd uintptr) bool {
+		sendErr = syscall.Sendmsg(int(fd), b, oob, nil, 0)
+		return sendErr != syscall.EAGAIN
+	})
+	if err != nil {
+		return err
+	}
+
+	return sendErr
+}
+
+// inheritedFiles returns the file descriptors passed by systemd, by name, and unsets the `LISTEN_*` environment variables so they are not inherited by child processes.
+// The file descriptors passed by the process that started the current one using `Upgrade` are merged in, along with the connection to report readiness.
+func inheritedFiles() (map[string][]*os.File, *net.UnixConn) {
+	fds := parseListenFDs(os.Getpid(), os.Getenv("LISTEN_PID"), os.Getenv("LISTEN_FDS"), os.Getenv("LISTEN_FDNAMES"))
+
+	_ = os.Unsetenv("LISTEN_PID")
+	_ = os.Unsetenv("LISTEN_FDS")
+	_ = os.Unsetenv("LISTEN_FDNAMES")
+
+	files := make(map[string][]*os.File, len(fds))
+	for _, l := range fds {
+		syscall.CloseOnExec(l.fd)
+		files[l.name] = append(files[l.name], os.NewFile(uintptr(l.fd), l.name))
+	}
+
+	upgraded, conn := upgradeFiles()
+	for name, fs := range upgraded {
+		files[name] = append(files[name], fs...)
+	}
+
+	return files, conn
+}
@@ -0,0 +1,239 @@
+//go:build unix
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// notifySocket listens on a systemd like notify socket and sets `NOTIFY_SOCKET` for the duration of the test.
+func notifySocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+
+	// unix socket paths are limited to ~100 characters, which t.TempDir() may exceed.
+	dir, err := os.MkdirTemp("", "sd")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = os.RemoveAll(dir) })
+
+	path := filepath.Join(dir, "notify")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	return conn
+}
+
+// readNotify reads the next notify message and the file descriptors passed along with it.
+func readNotify(t *testing.T, conn *net.UnixConn) (string, []int) {
+	t.Helper()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	b := make([]byte, 4096)
+	oob := make([]byte, 4096)
+	n, oobn, _, _, err := conn.ReadMsgUnix(b, oob)
+	require.NoError(t, err)
+
+	fds := []int{}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	require.NoError(t, err)
+	for _, m := range msgs {
+		rights, err := syscall.ParseUnixRights(&m)
+		require.NoError(t, err)
+		fds = append(fds, rights...)
+	}
+
+	return string(b[:n]), fds
+}
+
+func TestSystemdFDStore(t *testing.T) {
+	conn := notifySocket(t)
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithSystemdFDStore(), WithLogger(logger(t)), withSTDAPI(s))
+
+	msg, _ := readNotify(t, conn)
+	assert.Equal(t, "READY=1", msg)
+
+	ln, err := d.Listen("http", "tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	d.Defer(func(context.Context) { _ = ln.Close() })
+
+	d.ShutDown()
+	d.Wait()
+
+	msg, _ = readNotify(t, conn)
+	assert.Equal(t, "STOPPING=1", msg)
+
+	msg, fds := readNotify(t, conn)
+	assert.Equal(t, "FDSTORE=1\nFDNAME=http", msg)
+	require.Len(t, fds, 1)
+
+	// the stored file descriptor keeps the socket open after the listener is closed.
+	f := os.NewFile(uintptr(fds[0]), "http")
+	stored, err := net.FileListener(f)
+	require.NoError(t, err)
+	_ = f.Close()
+	assert.Equal(t, ln.Addr().String(), stored.Addr().String())
+	_ = stored.Close()
+}
+
+func TestSystemdNotifyDisabled(t *testing.T) {
+	conn := notifySocket(t)
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+	d.ShutDown()
+	d.Wait()
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, err := conn.Read(make([]byte, 64))
+	assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+}
+
+func TestSystemdShutdownStatus(t *testing.T) {
+	conn := notifySocket(t)
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithSystemdNotify(),
+		WithShutdownGraceDuration(time.Minute),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+	d.Defer(namedModule{}.Stop, namedModule{}.Stop)
+
+	d.ShutDown()
+	d.Wait()
+
+	msgs := []string{}
+	for range 5 {
+		msg, _ := readNotify(t, conn)
+		msgs = append(msgs, msg)
+	}
+
+	assert.Equal(t, []string{"READY=1", "STOPPING=1"}, msgs[:2])
+	assert.Regexp(t, `^STATUS=stopping: 0/2 callbacks done \(daemon\.namedModule\.Stop\)\nEXTEND_TIMEOUT_USEC=6\d{7}$`, msgs[2])
+	assert.Regexp(t, `^STATUS=stopping: 1/2 callbacks done \(daemon\.namedModule\.Stop\)\nEXTEND_TIMEOUT_USEC=6\d{7}$`, msgs[3])
+	assert.Equal(t, "STATUS=stopping: 2/2 callbacks done", msgs[4])
+}
+
+func TestSystemdReload(t *testing.T) {
+	conn := notifySocket(t)
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(context.Background(), WithSystemdNotify(), WithReloadSignal(nil), WithLogger(logger(t)), withSTDAPI(s))
+	defer func() {
+		d.ShutDown()
+		d.Wait()
+	}()
+
+	msg, _ := readNotify(t, conn)
+	assert.Equal(t, "READY=1", msg)
+
+	d.OnReload(func(context.Context) error { return errors.New("boom") })
+	assert.Error(t, d.Reload(t.Context()))
+
+	msg, _ = readNotify(t, conn)
+	assert.Regexp(t, `^RELOADING=1(\nMONOTONIC_USEC=\d+)?$`, msg)
+
+	msg, _ = readNotify(t, conn)
+	assert.Equal(t, "READY=1\nSTATUS=reload failed: boom", msg)
+}
+
+func TestSystemdWatchdog(t *testing.T) {
+	tests := map[string]struct {
+		check    func(context.Context) error
+		expected bool
+	}{
+		"healthy":   {check: func(context.Context) error { return nil }, expected: true},
+		"unhealthy": {check: func(context.Context) error { return errors.New("unhealthy") }, expected: false},
+	}
+
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			conn := notifySocket(t)
+			t.Setenv("WATCHDOG_USEC", "40000")
+			t.Setenv("WATCHDOG_PID", "")
+
+			s := newMockstdAPI(t)
+			s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+			s.EXPECT().SignalStop(mock.Anything).Once()
+
+			d := Start(context.Background(), WithSystemdNotify(), WithSystemdWatchdogCheck(tc.check), WithLogger(logger(t)), withSTDAPI(s))
+
+			msg, _ := readNotify(t, conn)
+			assert.Equal(t, "READY=1", msg)
+
+			require.NoError(t, conn.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+			b := make([]byte, 4096)
+			n, err := conn.Read(b)
+			if tc.expected {
+				require.NoError(t, err)
+				assert.Equal(t, "WATCHDOG=1", string(b[:n]))
+			} else {
+				assert.ErrorIs(t, err, os.ErrDeadlineExceeded)
+			}
+
+			d.ShutDown()
+			d.Wait()
+		})
+	}
+}
+
+func TestSystemdExtendTimeout(t *testing.T) {
+	conn := notifySocket(t)
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithSystemdNotify(),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+		func(oc *config) { oc.systemdExtendInterval = 20 * time.Millisecond },
+	)
+	// with an infinite grace period, the callbacks have no deadline to extend the timeout up to.
+	d.Defer(func(context.Context) { time.Sleep(100 * time.Millisecond) })
+
+	d.ShutDown()
+	d.Wait()
+
+	msgs := []string{}
+	for {
+		msg, _ := readNotify(t, conn)
+		msgs = append(msgs, msg)
+		if msg == "STATUS=stopping: 1/1 callbacks done" {
+			break
+		}
+	}
+
+	assert.Contains(t, msgs, "EXTEND_TIMEOUT_USEC=40000")
+}
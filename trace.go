@@ -0,0 +1,76 @@
+package daemon
+
+import (
+	"bytes"
+	"log/slog"
+	"runtime/trace"
+	"time"
+)
+
+// WithFlightRecorder keeps, from `Start` until the shutdown is done, a flight recorder of the go execution trace (see `trace.FlightRecorder`)
+// holding at least the last `window` of the trace (zero for the runtime's default).
+// The trace is written to the given file when the shutdown grace period is exceeded or when the daemon terminates immediately,
+// so bad shutdowns can be inspected using `go tool trace`.
+//
+// Regardless of this option, the lifecycle is traced: `Start`, `StartModules` (with a region for each module), `Reload`, `Upgrade` and the shutdown
+// are traced as the `daemon.start`, `daemon.start_modules`, `daemon.reload`, `daemon.upgrade` and `daemon.shutdown` tasks, the shutdown with a region
+// for each stage and shutdown callback, while `Arm` and `Ready` are traced as the `arm` and `ready` regions.
+func WithFlightRecorder(path string, window time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.flightRecorderFile = path
+		oc.flightRecorderWindow = window
+	}
+}
+
+// startFlightRecorder starts the flight recorder, if configured.
+func (o *Daemon) startFlightRecorder() {
+	if o.config.flightRecorderFile == "" {
+		return
+	}
+
+	fr := trace.NewFlightRecorder(trace.FlightRecorderConfig{MinAge: o.config.flightRecorderWindow})
+	if err := fr.Start(); err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to start flight recorder", slog.String("error", err.Error()))
+		return
+	}
+
+	o.flightRecorderMutex.Lock()
+	defer o.flightRecorderMutex.Unlock()
+	o.flightRecorder = fr
+}
+
+// dumpFlightRecorder writes the flight recorder's trace to the configured file, if the flight recorder is running.
+func (o *Daemon) dumpFlightRecorder() {
+	o.flightRecorderMutex.Lock()
+	defer o.flightRecorderMutex.Unlock()
+
+	if o.flightRecorder == nil {
+		return
+	}
+
+	buf := &bytes.Buffer{}
+	if _, err := o.flightRecorder.WriteTo(buf); err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to snapshot flight recorder", slog.String("error", err.Error()))
+		return
+	}
+
+	if err := writeFileAtomic(o.config.flightRecorderFile, buf.Bytes()); err != nil {
+		o.config.logger.ErrorContext(o.ctx, "failed to write flight recorder trace", slog.String("error", err.Error()))
+		return
+	}
+
+	o.config.logger.InfoContext(o.ctx, "flight recorder trace written", slog.String("path", o.config.flightRecorderFile))
+}
+
+// stopFlightRecorder stops the flight recorder, if running.
+func (o *Daemon) stopFlightRecorder() {
+	o.flightRecorderMutex.Lock()
+	defer o.flightRecorderMutex.Unlock()
+
+	if o.flightRecorder == nil {
+		return
+	}
+
+	o.flightRecorder.Stop()
+	o.flightRecorder = nil
+}
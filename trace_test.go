@@ -0,0 +1,59 @@
+package daemon
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlightRecorder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shutdown.trace")
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithFlightRecorder(path, time.Second),
+		WithShutdownGraceDuration(20*time.Millisecond),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	d.Defer(func(ctx context.Context) { <-ctx.Done() })
+
+	d.ShutDown()
+	d.Wait()
+
+	b, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotEmpty(t, b)
+}
+
+func TestFlightRecorderNotExceeded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "shutdown.trace")
+
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	d := Start(
+		context.Background(),
+		WithFlightRecorder(path, time.Second),
+		WithShutdownGraceDuration(time.Second),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.NoFileExists(t, path)
+}
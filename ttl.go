@@ -0,0 +1,47 @@
+package daemon
+
+import (
+	"log/slog"
+	"math/rand/v2"
+	"time"
+)
+
+// WithMaxUptime schedules a graceful shutdown after the daemon has been running for the given duration.
+// A random jitter in the range [-jitter, +jitter] is applied to the duration, so a fleet of instances started together will not be recycled at the same time.
+// Zero duration (the default) disables the max uptime.
+func WithMaxUptime(d, jitter time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.maxUptime = d
+		oc.maxUptimeJitter = jitter
+	}
+}
+
+// startTTL arms the max uptime timer, if configured.
+func (o *Daemon) startTTL() {
+	if o.config.maxUptime <= 0 {
+		return
+	}
+
+	uptime := withJitter(o.config.maxUptime, o.config.maxUptimeJitter)
+
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.ttlTimer = time.AfterFunc(uptime, func() {
+		o.config.logger.InfoContext(o.ctx, "max uptime reached", slog.Duration("uptime", uptime))
+		o.shutDownWith(ReasonTTLExpired, nil)
+	})
+}
+
+// withJitter returns d shifted by a random duration in the range [-jitter, +jitter], never going below zero.
+func withJitter(d, jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return d
+	}
+
+	d += time.Duration(rand.Int64N(2*int64(jitter)+1)) - jitter //nolint:gosec // no need for a secure random here.
+	if d < 0 {
+		return 0
+	}
+
+	return d
+}
@@ -0,0 +1,48 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMaxUptime(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(
+		context.Background(),
+		WithMaxUptime(10*time.Millisecond, 5*time.Millisecond),
+		WithLogger(logger(t)),
+		withSTDAPI(s),
+	)
+
+	d.Wait()
+
+	assert.Equal(t, ReasonTTLExpired, d.reason)
+}
+
+func TestWithJitter(t *testing.T) {
+	t.Run("no jitter", func(t *testing.T) {
+		assert.Equal(t, time.Hour, withJitter(time.Hour, 0))
+	})
+
+	t.Run("within range", func(t *testing.T) {
+		for range 100 {
+			d := withJitter(time.Hour, time.Minute)
+			assert.GreaterOrEqual(t, d, time.Hour-time.Minute)
+			assert.LessOrEqual(t, d, time.Hour+time.Minute)
+		}
+	})
+
+	t.Run("never negative", func(t *testing.T) {
+		for range 100 {
+			assert.GreaterOrEqual(t, withJitter(time.Millisecond, time.Hour), time.Duration(0))
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime/trace"
+	"strings"
+	"time"
+)
+
+// ErrUpgradeInProgress is returned by `Upgrade` when another upgrade is in progress.
+var ErrUpgradeInProgress = errors.New("upgrade already in progress")
+
+// EnvUpgradeFD is set by `Upgrade` in the environment of the new process, to the file descriptor of the unix socket connected to the old one.
+const EnvUpgradeFD = "DAEMON_UPGRADE_FD"
+
+// WithUpgradeSignal makes the daemon run `Upgrade` when the given signal (typically syscall.SIGUSR2) is received.
+func WithUpgradeSignal(sig os.Signal) DaemonConfigOption {
+	return func(oc *config) {
+		oc.upgradeSignal = sig
+	}
+}
+
+// WithUpgradeTimeout sets how long `Upgrade` waits for the new process to be ready. The default is 1 minute.
+func WithUpgradeTimeout(d time.Duration) DaemonConfigOption {
+	return func(oc *config) {
+		oc.upgradeTimeout = d
+	}
+}
+
+// Upgrade replaces the process with a new one running the current executable (e.g. a new binary at the same path), without downtime:
+//
+//  1. The new process is started with the same arguments, and the listeners created using `Listen` are passed to it over a unix socket,
+//     so `Listen` in the new process adopts them and the connections pending in their backlog are not lost.
+//  2. Upgrade waits for the new process to call `Ready`, up to the timeout set using `WithUpgradeTimeout`.
+//  3. Once ready, the graceful shutdown of the current daemon is initiated (`ReasonUpgrade`).
+//
+// If the new process fails to become ready, it gets killed, the current daemon keeps running and the error is returned.
+// It is only supported on unix.
+func (o *Daemon) Upgrade(ctx context.Context) error {
+	if !o.upgrading.CompareAndSwap(false, true) {
+		return ErrUpgradeInProgress
+	}
+	defer o.upgrading.Store(false)
+
+	ctx, task := trace.NewTask(ctx, "daemon.upgrade")
+	defer task.End()
+
+	o.listenersMutex.Lock()
+	listeners := o.listeners
+	o.listenersMutex.Unlock()
+
+	names := make([]string, 0, len(listeners))
+	files := make([]*os.File, 0, len(listeners))
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for _, l := range listeners {
+		f, err := l.file()
+		if err != nil {
+			return fmt.Errorf("passing listener %q: %w", l.name, err)
+		}
+		names = append(names, l.name)
+		files = append(files, f)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, o.config.upgradeTimeout)
+	defer cancel()
+
+	pid, err := spawnUpgrade(ctx, o.upgradeCommand(), names, files)
+	if err != nil {
+		return err
+	}
+
+	o.config.logger.InfoContext(o.ctx, "upgraded process is ready", slog.Int("pid", pid))
+	if o.config.systemdNotify {
+		// the new process becomes the main process of the service.
+		if err := sdNotify(fmt.Sprintf("MAINPID=%d", pid)); err != nil {
+			o.config.logger.ErrorContext(o.ctx, "failed to notify systemd", slog.String("error", err.Error()))
+		}
+	}
+
+	o.shutDownWith(ReasonUpgrade, nil)
+
+	return nil
+}
+
+// Ready reports to the process that started the current one using `Upgrade` that it is ready to take over. It is a no-op if the process was not started by an upgrade.
+// It should be called once the adopted listeners (see `Listen`) are served.
+func (o *Daemon) Ready() error {
+	defer trace.StartRegion(o.ctx, "ready").End()
+
+	return signalUpgradeReady()
+}
+
+// upgradeCommand returns the command that starts the new process: the current executable with the same arguments.
+func (o *Daemon) upgradeCommand() []string {
+	if o.config.upgradeCommand != nil {
+		return o.config.upgradeCommand
+	}
+
+	exe, err := os.Executable()
+	if err != nil {
+		exe = os.Args[0]
+	}
+
+	return append([]string{exe}, os.Args[1:]...)
+}
+
+// upgradeSignalHandler runs `Upgrade` in the background, logging its error.
+func (o *Daemon) upgradeSignalHandler(ctx context.Context) {
+	go func() {
+		if err := o.Upgrade(ctx); err != nil && !errors.Is(err, ErrUpgradeInProgress) {
+			o.config.logger.ErrorContext(ctx, "upgrade failed", slog.String("error", err.Error()))
+		}
+	}()
+}
+
+// joinUpgradeNames encodes the names of the passed listeners, like systemd's `LISTEN_FDNAMES`.
+func joinUpgradeNames(names []string) string {
+	return strings.Join(names, ":")
+}
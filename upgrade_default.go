@@ -0,0 +1,21 @@
+//go:build !unix
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+)
+
+// spawnUpgrade returns `errors.ErrUnsupported`, since passing file descriptors is unix only.
+func spawnUpgrade(context.Context, []string, []string, []*os.File) (int, error) {
+	return 0, errors.ErrUnsupported
+}
+
+// upgradeFiles returns nothing, since passing file descriptors is unix only.
+func upgradeFiles() (map[string][]*os.File, *net.UnixConn) { return nil, nil }
+
+// signalUpgradeReady is a no-op, since passing file descriptors is unix only.
+func signalUpgradeReady() error { return nil }
@@ -0,0 +1,188 @@
+//go:build unix
+
+package daemon
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// maxUpgradeFiles is the max number of file descriptors received from the process that started the current one using `Upgrade`.
+const maxUpgradeFiles = 256
+
+// spawnUpgrade starts the command, passes it the files over a unix socket and waits for it to report that it is ready.
+// It returns the pid of the new process, or kills it if it fails to become ready before ctx is done.
+func spawnUpgrade(ctx context.Context, command, names []string, files []*os.File) (int, error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return 0, fmt.Errorf("creating upgrade socket: %w", err)
+	}
+	syscall.CloseOnExec(fds[0])
+	syscall.CloseOnExec(fds[1])
+
+	local := os.NewFile(uintptr(fds[0]), "upgrade")
+	remote := os.NewFile(uintptr(fds[1]), "upgrade")
+
+	c, err := net.FileConn(local)
+	_ = local.Close()
+	if err != nil {
+		_ = remote.Close()
+		return 0, fmt.Errorf("creating upgrade socket: %w", err)
+	}
+	conn := c.(*net.UnixConn) //nolint:forcetypeassert // the socket is a unix one.
+	defer conn.Close()
+
+	cmd := exec.Command(command[0], command[1:]...) //nolint:gosec,noctx // the process re-executes itself and outlives the caller.
+	cmd.Env = append(os.Environ(), EnvUpgradeFD+"=3")
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = []*os.File{remote}
+
+	// the new process holds its own copy of the remote end once started, so it is closed here whether it started or not.
+	err = cmd.Start()
+	_ = remote.Close()
+	if err != nil {
+		return 0, err
+	}
+
+	if err := awaitUpgrade(ctx, conn, names, files); err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+
+		return 0, err
+	}
+
+	pid := cmd.Process.Pid
+
+	// the new process is not waited for, it takes over once the current one exits.
+	return pid, cmd.Process.Release()
+}
+
+// awaitUpgrade sends the files to the new process and waits for `READY=1`.
+func awaitUpgrade(ctx context.Context, conn *net.UnixConn, names []string, files []*os.File) error {
+	fds := make([]int, 0, len(files))
+	for _, f := range files {
+		fds = append(fds, int(f.Fd()))
+	}
+
+	if _, _, err := conn.WriteMsgUnix([]byte("FDNAMES="+joinUpgradeNames(names)), syscall.UnixRights(fds...), nil); err != nil {
+		return fmt.Errorf("passing listeners: %w", err)
+	}
+
+	// the read is interrupted once ctx is done.
+	stop := context.AfterFunc(ctx, func() {
+		_ = conn.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	b, err := io.ReadAll(conn)
+	switch {
+	case string(b) == "READY=1":
+		return nil
+	case err != nil && ctx.Err() != nil:
+		return fmt.Errorf("waiting for the new process to be ready: %w", ctx.Err())
+	case err != nil:
+		return fmt.Errorf("waiting for the new process to be ready: %w", err)
+	default:
+		return errors.New("the new process exited before being ready")
+	}
+}
+
+// upgradeFiles returns the file descriptors passed by the process that started the current one using `Upgrade`, by name,
+// and the connection to report readiness. It unsets `EnvUpgradeFD` so it is not inherited by child processes.
+func upgradeFiles() (map[string][]*os.File, *net.UnixConn) {
+	env := os.Getenv(EnvUpgradeFD)
+	_ = os.Unsetenv(EnvUpgradeFD)
+
+	fd, err := strconv.Atoi(env)
+	if err != nil {
+		return nil, nil
+	}
+
+	f := os.NewFile(uintptr(fd), "upgrade")
+	c, err := net.FileConn(f)
+	_ = f.Close()
+	if err != nil {
+		return nil, nil
+	}
+	conn, ok := c.(*net.UnixConn)
+	if !ok {
+		_ = c.Close()
+		return nil, nil
+	}
+
+	b := make([]byte, 4096)
+	oob := make([]byte, syscall.CmsgSpace(4*maxUpgradeFiles))
+	n, oobn, _, _, err := conn.ReadMsgUnix(b, oob)
+	if err != nil {
+		_ = conn.Close()
+		return nil, nil
+	}
+
+	return parseUpgradeMessage(string(b[:n]), oob[:oobn]), conn
+}
+
+// parseUpgradeMessage returns the files passed in the ancillary data, by the names given in the `FDNAMES=` message.
+func parseUpgradeMessage(msg string, oob []byte) map[string][]*os.File {
+	var fds []int
+	scms, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil
+	}
+	for i := range scms {
+		rights, err := syscall.ParseUnixRights(&scms[i])
+		if err != nil {
+			continue
+		}
+		fds = append(fds, rights...)
+	}
+
+	var names []string
+	if joined := strings.TrimPrefix(msg, "FDNAMES="); joined != "" {
+		names = strings.Split(joined, ":")
+	}
+
+	files := make(map[string][]*os.File, len(fds))
+	for i, fd := range fds {
+		syscall.CloseOnExec(fd)
+		if i >= len(names) {
+			// a file without a name can not be adopted.
+			_ = syscall.Close(fd)
+			continue
+		}
+		files[names[i]] = append(files[names[i]], os.NewFile(uintptr(fd), names[i]))
+	}
+
+	return files
+}
+
+// signalUpgradeReady writes `READY=1` to the process that started the current one using `Upgrade`, if any, and closes the connection.
+func signalUpgradeReady() error {
+	loadInherited()
+
+	inherited.mu.Lock()
+	defer inherited.mu.Unlock()
+
+	conn := inherited.upgrade
+	if conn == nil {
+		return nil
+	}
+	inherited.upgrade = nil
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte("READY=1")); err != nil {
+		return fmt.Errorf("reporting readiness to the upgrading process: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,107 @@
+//go:build unix
+
+package daemon
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUpgradeHelperProcess is the new process started by `TestUpgrade`: it adopts the passed listener and reports that it is ready.
+func TestUpgradeHelperProcess(t *testing.T) {
+	if os.Getenv("DAEMON_TEST_UPGRADE_HELPER") != "1" {
+		t.Skip("only run as the new process of TestUpgrade")
+	}
+
+	f := takeInheritedFile("http")
+	if f == nil {
+		os.Exit(3)
+	}
+	ln, err := net.FileListener(f)
+	if err != nil {
+		os.Exit(4)
+	}
+	_ = ln.Close()
+
+	if err := signalUpgradeReady(); err != nil {
+		os.Exit(5)
+	}
+	os.Exit(0)
+}
+
+func TestUpgrade(t *testing.T) {
+	t.Run("new process ready", func(t *testing.T) {
+		t.Setenv("DAEMON_TEST_UPGRADE_HELPER", "1")
+
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), func(oc *config) {
+			oc.upgradeCommand = []string{os.Args[0], "-test.run=^TestUpgradeHelperProcess$"}
+		})
+
+		var info ShutdownInfo
+		d.Defer(func(ctx context.Context) { info, _ = ReasonFromContext(ctx) })
+
+		ln, err := d.Listen("http", "tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		d.Defer(func(context.Context) { _ = ln.Close() })
+
+		require.NoError(t, d.Upgrade(context.Background()))
+		d.Wait()
+
+		assert.Equal(t, ReasonUpgrade, info.Reason)
+	})
+
+	t.Run("new process exits before being ready", func(t *testing.T) {
+		if _, err := exec.LookPath("sh"); err != nil {
+			t.Skip("sh is not available")
+		}
+
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), func(oc *config) {
+			oc.upgradeCommand = []string{"sh", "-c", "exit 0"}
+		})
+
+		require.Error(t, d.Upgrade(context.Background()))
+		assert.NoError(t, d.ctx.Err())
+
+		d.ShutDown()
+		d.Wait()
+	})
+
+	t.Run("new process not ready in time", func(t *testing.T) {
+		if _, err := exec.LookPath("sh"); err != nil {
+			t.Skip("sh is not available")
+		}
+
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s), WithUpgradeTimeout(50*time.Millisecond), func(oc *config) {
+			oc.upgradeCommand = []string{"sh", "-c", "exec sleep 10"}
+		})
+
+		require.ErrorIs(t, d.Upgrade(context.Background()), context.DeadlineExceeded)
+		assert.NoError(t, d.ctx.Err())
+
+		d.upgrading.Store(true)
+		require.ErrorIs(t, d.Upgrade(context.Background()), ErrUpgradeInProgress)
+
+		d.ShutDown()
+		d.Wait()
+	})
+}
@@ -0,0 +1,149 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync/atomic"
+	"time"
+)
+
+// ErrShutDown can be returned by the function given to `Watch` in order to initiate a graceful shutdown without a fatal error.
+var ErrShutDown = errors.New("shut down")
+
+// Watch consumes the given channel until it gets closed or the graceful shutdown is done, and calls f for every value received
+// before the shutdown process starts. Values received while shutting down are discarded, so that senders that keep sending
+// during their own close (e.g. an `Errors()` channel written to by a `Close` shutdown callback) never block.
+// If f returns `ErrShutDown` the graceful shutdown is initiated, while any other non nil error is pushed to the fatal errors channel.
+func Watch[T any](d *Daemon, ch <-chan T, f func(T) error) {
+	go func() {
+		for {
+			select {
+			case v, ok := <-ch:
+				if !ok {
+					return
+				}
+
+				// discard the values received while shutting down.
+				select {
+				case <-d.stopping:
+					continue
+				default:
+				}
+
+				err := f(v)
+				switch {
+				case err == nil:
+				case errors.Is(err, ErrShutDown):
+					d.ShutDown()
+				default:
+					d.pushFatalError(err)
+				}
+
+			// stop watching
+			case <-d.done:
+				return
+			}
+		}
+	}()
+}
+
+// Decision describes how an error received by `MonitorErrors` is handled.
+type Decision int
+
+const (
+	// DecisionFatal pushes the error to the fatal errors channel.
+	DecisionFatal Decision = iota
+	// DecisionLog logs the error as a warning and keeps monitoring.
+	DecisionLog
+	// DecisionIgnore drops the error silently.
+	DecisionIgnore
+)
+
+// MonitorErrors drains the given errors channel (e.g. the `Errors()` channel of a library) for the lifetime of the daemon,
+// and handles each error according to the decision of classify. A nil classify treats every error as fatal.
+// The errors received once the shutdown process has started are discarded, like in `Watch`.
+func (o *Daemon) MonitorErrors(ch <-chan error, classify func(error) Decision) {
+	Watch(o, ch, func(err error) error {
+		if err == nil {
+			return nil
+		}
+
+		d := DecisionFatal
+		if classify != nil {
+			d = classify(err)
+		}
+
+		switch d {
+		case DecisionLog:
+			o.config.logger.WarnContext(o.ctx, "error received", slog.String("error", err.Error()))
+			return nil
+		case DecisionIgnore:
+			return nil
+		default:
+			return err
+		}
+	})
+}
+
+// WatchContext makes the given context an additional stop condition: once it is done, the graceful shutdown is initiated
+// with the given reason (e.g. "leader_election_lost"). It stops watching once the shutdown process starts.
+func (o *Daemon) WatchContext(ctx context.Context, reason string) {
+	go func() {
+		select {
+		case <-ctx.Done():
+			cause := context.Cause(ctx)
+			o.config.logger.WarnContext(o.ctx, "watched context is done",
+				slog.String("reason", reason),
+				slog.String("error", ctx.Err().Error()),
+				slog.String("cause", cause.Error()),
+			)
+			o.shutDownWith(Reason(reason), cause)
+
+		// stop watching
+		case <-o.stopping:
+		}
+	}()
+}
+
+// watchInactivity blocks until the unix nano timestamp stored in last is older than timeout, and then calls fire with the elapsed duration.
+// It returns without calling fire if the shutdown process starts first.
+func (o *Daemon) watchInactivity(last *atomic.Int64, timeout time.Duration, fire func(elapsed time.Duration)) {
+	t := time.NewTimer(timeout)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			elapsed := time.Since(time.Unix(0, last.Load()))
+			if elapsed >= timeout {
+				fire(elapsed)
+				return
+			}
+			t.Reset(timeout - elapsed)
+
+		// stop the loop
+		case <-o.stopping:
+			return
+		}
+	}
+}
+
+// poll calls check every interval until it returns true or the shutdown process starts.
+func (o *Daemon) poll(interval time.Duration, check func() bool) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-t.C:
+			if check() {
+				return
+			}
+
+		// stop the loop
+		case <-o.stopping:
+			return
+		}
+	}
+}
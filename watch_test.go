@@ -0,0 +1,134 @@
+package daemon
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWatchContext(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	leader, lost := context.WithCancel(context.Background())
+	d.WatchContext(leader, "leadership_lost")
+	d.WatchContext(t.Context(), "never")
+
+	lost()
+	d.Wait()
+
+	assert.Equal(t, Reason("leadership_lost"), d.State().Reason)
+}
+
+func TestWatch(t *testing.T) {
+	t.Run("fatal error", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		// we specifically want a context that will not get cancelled at the end of the test
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		ch := make(chan int)
+		Watch(d, ch, func(i int) error {
+			if i > 1 {
+				return errors.New("too big")
+			}
+			return nil
+		})
+
+		ch <- 1
+		ch <- 2
+
+		d.Wait()
+
+		assert.Equal(t, ReasonFatalError, d.State().Reason)
+	})
+
+	t.Run("shut down", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		// we specifically want a context that will not get cancelled at the end of the test
+		d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+		// a closed channel stops the watch, without calling f.
+		closed := make(chan struct{})
+		Watch(d, closed, func(struct{}) error { return errors.New("unexpected") })
+		close(closed)
+
+		ch := make(chan string, 1)
+		Watch(d, ch, func(string) error { return ErrShutDown })
+		ch <- "done"
+
+		d.Wait()
+
+		assert.Equal(t, ReasonManual, d.State().Reason)
+	})
+}
+
+func TestMonitorErrors(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	errTemporary := errors.New("temporary")
+	errNoise := errors.New("noise")
+
+	ch := make(chan error)
+	d.MonitorErrors(ch, func(err error) Decision {
+		switch {
+		case errors.Is(err, errTemporary):
+			return DecisionLog
+		case errors.Is(err, errNoise):
+			return DecisionIgnore
+		default:
+			return DecisionFatal
+		}
+	})
+
+	ch <- errTemporary
+	ch <- errNoise
+	ch <- nil
+	assert.Equal(t, StatusRunning, d.State().Status)
+
+	ch <- errors.New("broken")
+
+	d.Wait()
+
+	assert.Equal(t, ReasonFatalError, d.State().Reason)
+}
+
+func TestMonitorErrorsWhileShuttingDown(t *testing.T) {
+	s := newMockstdAPI(t)
+	s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+	s.EXPECT().SignalStop(mock.Anything).Once()
+
+	// we specifically want a context that will not get cancelled at the end of the test
+	d := Start(context.Background(), WithLogger(logger(t)), withSTDAPI(s))
+
+	// like the Errors() channel of a client that reports the errors of its Close.
+	ch := make(chan error)
+	d.MonitorErrors(ch, nil)
+	d.OnShutDown(func(context.Context) {
+		ch <- errors.New("closing")
+		ch <- errors.New("closed")
+	})
+
+	d.ShutDown()
+	d.Wait()
+
+	assert.Equal(t, ReasonManual, d.State().Reason)
+	assert.NoError(t, d.Err())
+}
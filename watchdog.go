@@ -0,0 +1,67 @@
+package daemon
+
+import (
+	"log/slog"
+	"runtime"
+	"time"
+)
+
+// WatchdogAction describes what the daemon does when the application misses its heartbeat.
+type WatchdogAction int
+
+const (
+	// WatchdogShutDown initiates a graceful shutdown.
+	WatchdogShutDown WatchdogAction = iota
+	// WatchdogExit terminates the process immediately, without running the shutdown callbacks.
+	WatchdogExit
+)
+
+// WithWatchdog expects the application to call `Heartbeat()` at least once every interval.
+// If a heartbeat is missed, the daemon logs a dump of every go routine's stack and then acts according to the given action.
+// Zero interval (the default) disables the watchdog.
+func WithWatchdog(interval time.Duration, action WatchdogAction) DaemonConfigOption {
+	return func(oc *config) {
+		oc.watchdogInterval = interval
+		oc.watchdogAction = action
+	}
+}
+
+// Heartbeat reports to the watchdog configured using `WithWatchdog` that the application is alive.
+func (o *Daemon) Heartbeat() {
+	o.lastHeartbeat.Store(time.Now().UnixNano())
+}
+
+// startWatchdog spawns a go routine that will act once a heartbeat is missed. It stops once the shutdown process starts.
+func (o *Daemon) startWatchdog() {
+	if o.config.watchdogInterval <= 0 {
+		return
+	}
+
+	o.Heartbeat()
+
+	go o.watchInactivity(&o.lastHeartbeat, o.config.watchdogInterval, func(elapsed time.Duration) {
+		o.config.logger.ErrorContext(o.ctx, "watchdog heartbeat missed",
+			slog.Duration("since_last_heartbeat", elapsed),
+			slog.String("goroutines", string(goroutineDump())),
+		)
+
+		if o.config.watchdogAction == WatchdogExit {
+			o.forceExit()
+			return
+		}
+
+		o.shutDownWith(ReasonWatchdog, nil)
+	})
+}
+
+// goroutineDump returns the stack traces of all the go routines.
+func goroutineDump() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
@@ -0,0 +1,63 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWatchdog(t *testing.T) {
+	t.Run("shutdown", func(t *testing.T) {
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+
+		// we specifically want a context that will not get cancelled at the end of the test
+		d := Start(
+			context.Background(),
+			WithWatchdog(30*time.Millisecond, WatchdogShutDown),
+			WithLogger(logger(t)),
+			withSTDAPI(s),
+		)
+
+		for range 3 {
+			time.Sleep(10 * time.Millisecond)
+			d.Heartbeat()
+		}
+
+		d.Wait()
+
+		assert.Equal(t, ReasonWatchdog, d.State().Reason)
+	})
+
+	t.Run("exit", func(t *testing.T) {
+		exited := make(chan struct{})
+
+		s := newMockstdAPI(t)
+		s.EXPECT().SignalNotify(mock.Anything, mock.Anything).Once()
+		s.EXPECT().SignalStop(mock.Anything).Once()
+		s.EXPECT().OSExit(2).Run(func(code int) { close(exited) }).Once()
+
+		// we specifically want a context that will not get cancelled at the end of the test
+		d := Start(
+			context.Background(),
+			WithWatchdog(10*time.Millisecond, WatchdogExit),
+			WithLogger(logger(t)),
+			withSTDAPI(s),
+		)
+
+		<-exited
+
+		d.ShutDown()
+		d.Wait()
+
+		assert.Equal(t, ReasonManual, d.State().Reason)
+	})
+}
+
+func TestGoroutineDump(t *testing.T) {
+	assert.Contains(t, string(goroutineDump()), "TestGoroutineDump")
+}